@@ -1,11 +1,45 @@
 package main
 
-import "go-crud-gin/cmd/server/app"
+import (
+	"go-crud-gin/cmd/server/app"
+	"go-crud-gin/internal/platform/database"
+	loggerpkg "go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+	"os"
+)
+
+// newStore keeps the data in the database of DATABASE_URL when it is set,
+// and in the data directory otherwise.
+func newStore(logger loggerpkg.Logger) (repositories.Store, error) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return repositories.NewFileStore(logger, repositories.DefaultFileStoreOptions())
+	}
+
+	options := repositories.DefaultSQLStoreOptions()
+	if dialect := os.Getenv("DATABASE_DIALECT"); dialect != "" {
+		options.Dialect = repositories.Dialect(dialect)
+	}
+
+	db, err := database.Open(string(options.Dialect), dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return repositories.NewSQLStore(logger, options, db)
+}
 
 func main() {
-	application := app.NewAppBuilder().Build()
+	logger := loggerpkg.NewLocalLogger()
+
+	store, err := newStore(logger)
+	if err != nil {
+		panic(err)
+	}
+
+	application := app.NewAppBuilder().WithLogger(logger).WithStore(store).Build()
 
-	err := application.Run()
+	err = application.Run()
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,109 @@
+// Command migrate applies or reverts the migrations of the SQL store:
+//
+//	go run ./cmd/migrate [-dialect sqlite|postgres] [-dsn DSN] up
+//	go run ./cmd/migrate [-dialect sqlite|postgres] [-dsn DSN] [-steps N] down
+//	go run ./cmd/migrate [-dialect sqlite|postgres] [-dsn DSN] version
+//
+// The dialect and the DSN default to the DATABASE_DIALECT and DATABASE_URL
+// environment variables used by cmd/api, and the dialect to sqlite when
+// DATABASE_DIALECT is not set either.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-crud-gin/internal/platform/database"
+	loggerpkg "go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+	"os"
+)
+
+func main() {
+	defaultDialect := os.Getenv("DATABASE_DIALECT")
+	if defaultDialect == "" {
+		defaultDialect = string(repositories.DialectSQLite)
+	}
+
+	dialect := flag.String("dialect", defaultDialect, "database dialect, sqlite or postgres")
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"), "database connection string")
+	steps := flag.Int("steps", 1, "number of migrations to revert with down")
+	flag.Usage = usage
+	flag.Parse()
+
+	if err := validate(*dialect, *dsn, *steps, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*dialect, *dsn, *steps, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] up|down|version\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// validate rejects the arguments before connecting, since an empty dialect or
+// DSN would otherwise fail with an unclear driver error.
+func validate(dialect, dsn string, steps int, command string) error {
+	switch repositories.Dialect(dialect) {
+	case repositories.DialectSQLite, repositories.DialectPostgres:
+	default:
+		return fmt.Errorf("unknown dialect %q, use sqlite or postgres", dialect)
+	}
+
+	if dsn == "" {
+		return fmt.Errorf("missing DSN, set -dsn or DATABASE_URL")
+	}
+
+	switch command {
+	case "up", "version":
+	case "down":
+		if steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unknown command %q, use up, down or version", command)
+	}
+
+	return nil
+}
+
+func run(dialect, dsn string, steps int, command string) error {
+	logger := loggerpkg.NewLocalLogger()
+
+	db, err := database.Open(dialect, dsn)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	switch command {
+	case "up":
+		applied, err := repositories.MigrateUp(db, repositories.Dialect(dialect))
+		logger.Infof("[Migrate] %d migrations applied", applied)
+		if err != nil {
+			return err
+		}
+	case "down":
+		reverted, err := repositories.MigrateDown(db, repositories.Dialect(dialect), steps)
+		logger.Infof("[Migrate] %d migrations reverted", reverted)
+		if err != nil {
+			return err
+		}
+	}
+
+	version, err := repositories.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("[Migrate] Database schema at version %d", version)
+
+	return nil
+}
@@ -1,11 +1,26 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go-crud-gin/cmd/server/handlers"
 	"go-crud-gin/cmd/server/wrappers"
+	"go-crud-gin/config"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
 	authenticatorpkg "go-crud-gin/internal/platform/authenticator"
 	loggerpkg "go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/routes"
+	"go-crud-gin/internal/repositories"
 	"go-crud-gin/internal/services"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,76 +33,288 @@ type app struct {
 	router        *gin.Engine
 	authenticator authenticatorpkg.Authenticator
 	logger        loggerpkg.Logger
+	routesTable   routes.Table
+	routesLoader  routes.Loader
+
+	routesOptions      routes.Options
+	errorOptions       wrappers.ErrorOptions
+	concurrencyOptions wrappers.ConcurrencyOptions
+	seedOptions        services.SeedOptions
+	usersOptions       services.UsersOptions
+	permissionsOptions services.PermissionsOptions
 
 	// Services
-	usersService       services.UsersService
-	permissionsService services.PermissionsService
+	organizationsService  services.OrganizationsService
+	usersService          services.UsersService
+	userAttributesService services.UserAttributesService
+	usersImportService    services.UsersImportService
+	permissionsService    services.PermissionsService
+	groupsService         services.GroupsService
+	accessRequestsService services.AccessRequestsService
+	authorizationService  services.AuthorizationService
+	seedService           services.SeedService
 
 	// Handlers
-	authHandler        *handlers.AuthHandler
-	usersHandler       *handlers.UsersHandler
-	permissionsHandler *handlers.PermissionsHandler
+	organizationsHandler  *handlers.OrganizationsHandler
+	authHandler           *handlers.AuthHandler
+	usersHandler          *handlers.UsersHandler
+	usersImportHandler    *handlers.UsersImportHandler
+	permissionsHandler    *handlers.PermissionsHandler
+	groupsHandler         *handlers.GroupsHandler
+	accessRequestsHandler *handlers.AccessRequestsHandler
+	authorizationHandler  *handlers.AuthorizationHandler
+	metaHandler           *handlers.MetaHandler
 
 	// Wrappers
 	authenticatorWrapper *wrappers.AuthenticatorWrapper
 	errorWrapper         *wrappers.ErrorWrapper
+	concurrencyWrapper   *wrappers.ConcurrencyWrapper
 }
 
 func (app *app) setupDependencies() {
 	app.logger.Infof("[APP] Setting up dependencies...")
 
+	// Routes
+	app.routesLoader = routes.NewLoader(app.logger, app.routesOptions, app.routesTable, func(name string) bool {
+		return app.permissionsService.GetPermissionByName(services.GlobalOrganizationID, name) != nil
+	})
+
 	// Handlers
-	app.authHandler = handlers.NewAuthHandler(app.logger, app.authenticator, app.usersService, app.permissionsService)
-	app.usersHandler = handlers.NewUsersHandler(app.logger, app.usersService)
-	app.permissionsHandler = handlers.NewPermissionsHandler(app.logger, app.permissionsService, app.usersService)
+	app.organizationsHandler = handlers.NewOrganizationsHandler(app.logger, app.organizationsService)
+	app.authHandler = handlers.NewAuthHandler(app.logger, app.authenticator, app.usersService, app.permissionsService, app.organizationsService)
+	app.usersHandler = handlers.NewUsersHandler(app.logger, app.usersService, app.permissionsService, app.groupsService, app.userAttributesService)
+	app.usersImportHandler = handlers.NewUsersImportHandler(app.logger, app.usersImportService, app.permissionsService)
+	app.permissionsHandler = handlers.NewPermissionsHandler(app.logger, app.permissionsService, app.usersService, app.groupsService, app.routesTable, app.routesLoader)
+	app.groupsHandler = handlers.NewGroupsHandler(app.logger, app.groupsService, app.permissionsService, app.usersService)
+	app.accessRequestsHandler = handlers.NewAccessRequestsHandler(app.logger, app.accessRequestsService, app.permissionsService, app.usersService)
+	app.authorizationHandler = handlers.NewAuthorizationHandler(app.logger, app.authorizationService, app.routesTable)
+	app.metaHandler = handlers.NewMetaHandler(app.logger, app.routesTable, app.routesLoader)
 
 	// Wrappers
-	app.authenticatorWrapper = wrappers.NewAuthentiatorWrapper(app.logger, app.authenticator, app.usersService)
-	app.errorWrapper = wrappers.NewErrorWrapper(app.logger)
+	app.authenticatorWrapper = wrappers.NewAuthentiatorWrapper(app.logger, app.authenticator, app.usersService, app.routesTable, app.permissionsOptions.PlatformAdminPermission)
+	app.errorWrapper = wrappers.NewErrorWrapper(app.logger, app.errorOptions)
+	app.concurrencyWrapper = wrappers.NewConcurrencyWrapper(app.logger, app.concurrencyOptions)
 
 	app.logger.Infof("[APP] Dependencies setted up!")
 }
 
+// handle registers the route in gin and in the routes loader. Its
+// requirements come from the routes config, see loadRoutes.
+func (app *app) handle(group *gin.RouterGroup, method, relativePath string, handler func(c *gin.Context) error) {
+	// Same as gin's joinPaths, so the path matches c.FullPath().
+	fullPath := path.Join(group.BasePath(), relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(fullPath, "/") {
+		fullPath += "/"
+	}
+
+	app.routesLoader.Register(method, fullPath)
+
+	group.Handle(method, relativePath, app.errorWrapper.Wrap(app.concurrencyWrapper.Wrap(app.authenticatorWrapper.Wrap(handler))))
+}
+
 func (app *app) setupRouter() {
 	app.logger.Infof("[APP] Setting up routes...")
 
+	organizations := app.router.Group("/organizations")
+	app.handle(organizations, http.MethodGet, "/", app.organizationsHandler.GetOrganizations)
+	app.handle(organizations, http.MethodPost, "/", app.organizationsHandler.CreateOrganization)
+	app.handle(organizations, http.MethodGet, "/name/:organizationName", app.organizationsHandler.GetOrganizationByName)
+
 	auth := app.router.Group("/auth")
-	auth.POST("/logIn", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.authHandler.LogIn, []string{})))
-	auth.POST("/signUp", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.authHandler.SignUp, []string{})))
+	app.handle(auth, http.MethodPost, "/logIn", app.authHandler.LogIn)
+	app.handle(auth, http.MethodPost, "/signUp", app.authHandler.SignUp)
 
 	users := app.router.Group("/users")
-	users.GET("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.usersHandler.GetUsers, []string{"users_read", "users_full"})))
-	users.GET("/id/:id", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.usersHandler.GetUserByID, []string{"users_read", "users_full"})))
+	app.handle(users, http.MethodGet, "/", app.usersHandler.GetUsers)
+	app.handle(users, http.MethodGet, "/id/:id", app.usersHandler.GetUserByID)
+	app.handle(users, http.MethodGet, "/public-id/:publicId", app.usersHandler.GetUserByPublicID)
+	app.handle(users, http.MethodPost, "/import", app.usersImportHandler.ImportUsers)
+	app.handle(users, http.MethodGet, "/export", app.usersImportHandler.ExportUsers)
+	app.handle(users, http.MethodGet, "/attributes", app.usersHandler.GetUserAttributes)
+	app.handle(users, http.MethodPut, "/attributes/:attributeName", app.usersHandler.SetUserAttribute)
+	app.handle(users, http.MethodDelete, "/attributes/:attributeName", app.usersHandler.DeleteUserAttribute)
 
 	userActions := users.Group("/username/:username")
-	userActions.GET("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.usersHandler.GetUserByUsername, []string{"users_read", "users_full"})))
-	userActions.DELETE("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.usersHandler.DeleteUser, []string{"users_write", "users_full"})))
-	userActions.GET("/permissions", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.GetPermissionsForUser, []string{"users_read", "users_full"})))
+	app.handle(userActions, http.MethodGet, "/", app.usersHandler.GetUserByUsername)
+	app.handle(userActions, http.MethodPatch, "/", app.usersHandler.UpdateUser)
+	app.handle(userActions, http.MethodDelete, "/", app.usersHandler.DeleteUser)
+	app.handle(userActions, http.MethodPost, "/restore", app.usersHandler.RestoreUser)
+	app.handle(userActions, http.MethodPost, "/suspend", app.usersHandler.SuspendUser)
+	app.handle(userActions, http.MethodPost, "/reactivate", app.usersHandler.ReactivateUser)
+	app.handle(userActions, http.MethodGet, "/permissions", app.permissionsHandler.GetPermissionsForUser)
 
 	permissions := app.router.Group("/permissions")
-	permissions.GET("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.GetPermissions, []string{"permissions_read", "permissions_full"})))
-	permissions.POST("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.CreatePermission, []string{"permissions_write", "permissions_full"})))
-	permissions.GET("/id/:id", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.GetPermissionByID, []string{"permissions_read", "permissions_full"})))
-	permissions.GET("/name/:permissionName", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.GetPermissionByName, []string{"permissions_read", "permissions_full"})))
-	permissions.DELETE("/name/:permissionName", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.DeletePermission, []string{"permissions_write", "permissions_full"})))
+	app.handle(permissions, http.MethodGet, "/", app.permissionsHandler.GetPermissions)
+	app.handle(permissions, http.MethodPost, "/", app.permissionsHandler.CreatePermission)
+	app.handle(permissions, http.MethodGet, "/id/:id", app.permissionsHandler.GetPermissionByID)
+	app.handle(permissions, http.MethodGet, "/public-id/:publicId", app.permissionsHandler.GetPermissionByPublicID)
+	app.handle(permissions, http.MethodGet, "/name/:permissionName", app.permissionsHandler.GetPermissionByName)
+	app.handle(permissions, http.MethodPost, "/bulk", app.permissionsHandler.BulkUserPermissions)
+	app.handle(permissions, http.MethodGet, "/name/:permissionName/users", app.permissionsHandler.GetPermissionHolders)
+	app.handle(permissions, http.MethodPatch, "/name/:permissionName", app.permissionsHandler.UpdatePermission)
+	app.handle(permissions, http.MethodDelete, "/name/:permissionName", app.permissionsHandler.DeletePermission)
 
 	userPermissions := userActions.Group("/permission/:permissionName")
-	userPermissions.POST("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.GrantPermissionToUser, []string{"grant_permission"})))
-	userPermissions.DELETE("/", app.errorWrapper.Wrap(app.authenticatorWrapper.Wrap(app.permissionsHandler.RevokePermissionToUser, []string{"revoke_permission"})))
+	app.handle(userPermissions, http.MethodPost, "/", app.permissionsHandler.GrantPermissionToUser)
+	app.handle(userPermissions, http.MethodDelete, "/", app.permissionsHandler.RevokePermissionToUser)
+
+	groups := app.router.Group("/groups")
+	app.handle(groups, http.MethodGet, "/", app.groupsHandler.GetGroups)
+	app.handle(groups, http.MethodPost, "/", app.groupsHandler.CreateGroup)
+
+	groupActions := groups.Group("/name/:groupName")
+	app.handle(groupActions, http.MethodGet, "/", app.groupsHandler.GetGroupByName)
+	app.handle(groupActions, http.MethodDelete, "/", app.groupsHandler.DeleteGroup)
+	app.handle(groupActions, http.MethodGet, "/members", app.groupsHandler.GetGroupMembers)
+	app.handle(groupActions, http.MethodPost, "/members", app.groupsHandler.AddGroupMembers)
+	app.handle(groupActions, http.MethodDelete, "/members/:username", app.groupsHandler.RemoveGroupMember)
+	app.handle(groupActions, http.MethodGet, "/permissions", app.groupsHandler.GetPermissionsForGroup)
+
+	groupPermissions := groupActions.Group("/permission/:permissionName")
+	app.handle(groupPermissions, http.MethodPost, "/", app.groupsHandler.GrantPermissionToGroup)
+	app.handle(groupPermissions, http.MethodDelete, "/", app.groupsHandler.RevokePermissionToGroup)
+
+	accessRequests := app.router.Group("/access-requests")
+	app.handle(accessRequests, http.MethodPost, "/", app.accessRequestsHandler.CreateAccessRequest)
+	app.handle(accessRequests, http.MethodGet, "/", app.accessRequestsHandler.GetAccessRequests)
+	app.handle(accessRequests, http.MethodGet, "/mine", app.accessRequestsHandler.GetMyAccessRequests)
+
+	accessRequestActions := accessRequests.Group("/id/:id")
+	app.handle(accessRequestActions, http.MethodGet, "/", app.accessRequestsHandler.GetAccessRequestByID)
+	app.handle(accessRequestActions, http.MethodPost, "/approve", app.accessRequestsHandler.ApproveAccessRequest)
+	app.handle(accessRequestActions, http.MethodPost, "/deny", app.accessRequestsHandler.DenyAccessRequest)
+
+	authz := app.router.Group("/authz")
+	app.handle(authz, http.MethodPost, "/check", app.authorizationHandler.Check)
+	app.handle(authz, http.MethodPost, "/check/batch", app.authorizationHandler.BatchCheck)
+
+	meta := app.router.Group("/meta")
+	app.handle(meta, http.MethodGet, "/routes", app.metaHandler.GetRoutes)
+	app.handle(meta, http.MethodPost, "/routes/reload", app.metaHandler.ReloadRoutes)
 
 	app.logger.Infof("[APP] Routes setted up!")
 }
 
+// loadSeed applies the seed file, or the embedded one when it does not exist.
+func (app *app) loadSeed() {
+	data, err := os.ReadFile(app.seedOptions.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		app.logger.Infof("[APP] %s not found, using the embedded seed", app.seedOptions.Path)
+		data, err = config.Seed, nil
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	var seed models.Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		panic(fmt.Errorf("invalid seed: %w", err))
+	}
+
+	if err := app.seedService.Apply(seed); err != nil {
+		panic(err)
+	}
+}
+
+// loadRoutes fills the routes table from the routes config. The application
+// does not start with an invalid config, since its routes would be unprotected.
+func (app *app) loadRoutes() {
+	err := app.routesLoader.Load()
+	if err == nil {
+		return
+	}
+
+	var appError *apperror.AppError
+	if errors.As(err, &appError) {
+		for field, message := range appError.ValidationDetails {
+			app.logger.Infof("[APP] Invalid routes config %s: %s", field, message)
+		}
+	}
+
+	panic(err)
+}
+
+// watchRoutesConfig reloads the routes config when the process receives SIGHUP.
+func (app *app) watchRoutesConfig() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			func() {
+				defer app.recoverReadError("Routes config not reloaded")
+
+				err := app.routesLoader.Load()
+				if err != nil {
+					app.logger.Infof("[APP] Routes config not reloaded: %v", err)
+				}
+			}()
+		}
+	}()
+}
+
+// recoverReadError logs the failed store reads of the background tasks,
+// which gin does not recover from like it does for the requests, so the
+// next run tries again. Any other panic is raised again.
+func (app *app) recoverReadError(message string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	readErr, ok := recovered.(*repositories.ReadError)
+	if !ok {
+		panic(recovered)
+	}
+
+	app.logger.Infof("[APP] %s: %v", message, readErr)
+}
+
+// purgeDeletedUsers periodically removes the users deleted longer than the
+// retention period ago, along with their grants and memberships, and stores
+// the reactivation of the users whose suspension expired and the expiration
+// of the pending access requests past their deadline.
+func (app *app) purgeDeletedUsers() {
+	ticker := time.NewTicker(app.usersOptions.PurgeInterval)
+
+	go func() {
+		for range ticker.C {
+			app.concurrencyWrapper.Exclusive(func() {
+				defer app.recoverReadError("Deleted users not purged")
+
+				app.usersService.ReactivateExpiredSuspensions()
+				app.accessRequestsService.ExpirePendingAccessRequests()
+
+				for _, userID := range app.usersService.PurgeDeletedUsers() {
+					if err := app.permissionsService.DeletePermissionsForUser(userID); err != nil {
+						app.logger.Infof("[APP] Grants of purged user %d not deleted: %v", userID, err)
+					}
+
+					if err := app.groupsService.RemoveUserFromGroups(userID); err != nil {
+						app.logger.Infof("[APP] Memberships of purged user %d not deleted: %v", userID, err)
+					}
+				}
+			})
+		}
+	}()
+}
+
 func (app *app) setup() {
 	app.logger.Infof("[APP] Setting up application...")
 
 	app.setupDependencies()
 	app.setupRouter()
+	app.loadSeed()
+	app.loadRoutes()
 
 	app.logger.Infof("[APP] Application setted up!")
 }
 
 func (app *app) Run() error {
+	app.watchRoutesConfig()
+	app.purgeDeletedUsers()
+
 	return app.router.Run(":8080")
 }
 
@@ -95,6 +322,14 @@ func newApp(
 	router *gin.Engine,
 	logger loggerpkg.Logger,
 	authenticator authenticatorpkg.Authenticator,
+	routesOptions *routes.Options,
+	errorOptions *wrappers.ErrorOptions,
+	concurrencyOptions *wrappers.ConcurrencyOptions,
+	seedOptions *services.SeedOptions,
+	usersOptions *services.UsersOptions,
+	permissionsOptions *services.PermissionsOptions,
+	accessRequestsOptions *services.AccessRequestsOptions,
+	store repositories.Store,
 ) App {
 	if router == nil {
 		router = gin.Default()
@@ -108,18 +343,84 @@ func newApp(
 		authenticator = authenticatorpkg.NewLocalAuthenticator(logger)
 	}
 
+	if routesOptions == nil {
+		defaultOptions := routes.DefaultOptions()
+		routesOptions = &defaultOptions
+	}
+
+	if errorOptions == nil {
+		defaultOptions := wrappers.DefaultErrorOptions()
+		errorOptions = &defaultOptions
+	}
+
+	if concurrencyOptions == nil {
+		defaultOptions := wrappers.DefaultConcurrencyOptions()
+		concurrencyOptions = &defaultOptions
+	}
+
+	if seedOptions == nil {
+		defaultOptions := services.DefaultSeedOptions()
+		seedOptions = &defaultOptions
+	}
+
+	if store == nil {
+		store = repositories.NewMemoryStore()
+	}
+
 	// Services
-	usersService := services.NewUsersService(logger)
-	permissionsService := services.NewPermissionsService(logger)
+	organizationsService, err := services.NewOrganizationsService(logger, store.Organizations())
+	if err != nil {
+		panic(err)
+	}
+
+	if usersOptions == nil {
+		defaultOptions := services.DefaultUsersOptions()
+		usersOptions = &defaultOptions
+	}
+
+	usersService := services.NewUsersService(logger, *usersOptions, store.Users())
+	if permissionsOptions == nil {
+		defaultOptions := services.DefaultPermissionsOptions()
+		permissionsOptions = &defaultOptions
+	}
+
+	userAttributesService := services.NewUserAttributesService(logger, store.UserAttributes())
+	groupsService := services.NewGroupsService(logger, store.Groups())
+	permissionsService := services.NewPermissionsService(logger, *permissionsOptions, usersService, groupsService, store.Permissions(), store.Grants())
+
+	if accessRequestsOptions == nil {
+		defaultOptions := services.DefaultAccessRequestsOptions()
+		accessRequestsOptions = &defaultOptions
+	}
+
+	accessRequestsService := services.NewAccessRequestsService(logger, *accessRequestsOptions, permissionsService, store.AccessRequests())
+	usersImportService := services.NewUsersImportService(logger, organizationsService, usersService, permissionsService, userAttributesService)
+	authorizationService := services.NewAuthorizationService(logger, usersService, permissionsService, groupsService)
+	seedService := services.NewSeedService(logger, organizationsService, usersService, permissionsService, store.Settings())
 
 	app := &app{
 		router:        router,
 		authenticator: authenticator,
 		logger:        logger,
+		routesTable:   routes.NewTable(),
+
+		routesOptions:      *routesOptions,
+		errorOptions:       *errorOptions,
+		concurrencyOptions: *concurrencyOptions,
+		seedOptions:        *seedOptions,
+		usersOptions:       *usersOptions,
+		permissionsOptions: *permissionsOptions,
 
 		// Services
-		usersService:       usersService,
-		permissionsService: permissionsService,
+		organizationsService:  organizationsService,
+		usersService:          usersService,
+		userAttributesService: userAttributesService,
+		usersImportService:    usersImportService,
+		permissionsService:    permissionsService,
+		groupsService:         groupsService,
+		accessRequestsService: accessRequestsService,
+		authorizationService:  authorizationService,
+		seedService:           seedService,
 	}
 
 	app.setup()
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"go-crud-gin/cmd/server/wrappers"
 	authenticatorpkg "go-crud-gin/internal/platform/authenticator"
 	loggerpkg "go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/routes"
+	"go-crud-gin/internal/repositories"
+	"go-crud-gin/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,12 +16,29 @@ type AppBuilder interface {
 	WithRouter(router *gin.Engine) *appBuilder
 	WithLogger(logger loggerpkg.Logger) *appBuilder
 	WithAuthenticator(authenticator authenticatorpkg.Authenticator) *appBuilder
+	WithRoutesOptions(options routes.Options) *appBuilder
+	WithErrorOptions(options wrappers.ErrorOptions) *appBuilder
+	WithConcurrencyOptions(options wrappers.ConcurrencyOptions) *appBuilder
+	WithSeedOptions(options services.SeedOptions) *appBuilder
+	WithUsersOptions(options services.UsersOptions) *appBuilder
+	WithPermissionsOptions(options services.PermissionsOptions) *appBuilder
+	WithAccessRequestsOptions(options services.AccessRequestsOptions) *appBuilder
+	WithStore(store repositories.Store) *appBuilder
 }
 
 type appBuilder struct {
 	router        *gin.Engine
 	authenticator authenticatorpkg.Authenticator
 	logger        loggerpkg.Logger
+
+	routesOptions         *routes.Options
+	errorOptions          *wrappers.ErrorOptions
+	concurrencyOptions    *wrappers.ConcurrencyOptions
+	seedOptions           *services.SeedOptions
+	usersOptions          *services.UsersOptions
+	permissionsOptions    *services.PermissionsOptions
+	accessRequestsOptions *services.AccessRequestsOptions
+	store                 repositories.Store
 }
 
 func (builder *appBuilder) WithRouter(router *gin.Engine) *appBuilder {
@@ -35,11 +56,60 @@ func (builder *appBuilder) WithAuthenticator(authenticator authenticatorpkg.Auth
 	return builder
 }
 
+func (builder *appBuilder) WithRoutesOptions(options routes.Options) *appBuilder {
+	builder.routesOptions = &options
+	return builder
+}
+
+func (builder *appBuilder) WithErrorOptions(options wrappers.ErrorOptions) *appBuilder {
+	builder.errorOptions = &options
+	return builder
+}
+
+func (builder *appBuilder) WithConcurrencyOptions(options wrappers.ConcurrencyOptions) *appBuilder {
+	builder.concurrencyOptions = &options
+	return builder
+}
+
+func (builder *appBuilder) WithSeedOptions(options services.SeedOptions) *appBuilder {
+	builder.seedOptions = &options
+	return builder
+}
+
+func (builder *appBuilder) WithUsersOptions(options services.UsersOptions) *appBuilder {
+	builder.usersOptions = &options
+	return builder
+}
+
+func (builder *appBuilder) WithPermissionsOptions(options services.PermissionsOptions) *appBuilder {
+	builder.permissionsOptions = &options
+	return builder
+}
+
+func (builder *appBuilder) WithAccessRequestsOptions(options services.AccessRequestsOptions) *appBuilder {
+	builder.accessRequestsOptions = &options
+	return builder
+}
+
+// WithStore replaces the in-memory storage of the data of the services.
+func (builder *appBuilder) WithStore(store repositories.Store) *appBuilder {
+	builder.store = store
+	return builder
+}
+
 func (builder *appBuilder) Build() App {
 	return newApp(
 		builder.router,
 		builder.logger,
 		builder.authenticator,
+		builder.routesOptions,
+		builder.errorOptions,
+		builder.concurrencyOptions,
+		builder.seedOptions,
+		builder.usersOptions,
+		builder.permissionsOptions,
+		builder.accessRequestsOptions,
+		builder.store,
 	)
 }
 
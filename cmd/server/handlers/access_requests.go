@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/requests"
+	"go-crud-gin/internal/responses"
+	"go-crud-gin/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessRequestsHandler struct {
+	BaseHandler
+
+	accessRequestsService services.AccessRequestsService
+	permissionsService    services.PermissionsService
+	usersService          services.UsersService
+}
+
+func (handler *AccessRequestsHandler) usernameByID(userID int) string {
+	user := handler.usersService.GetByID(services.AllOrganizations, userID)
+	if user == nil {
+		return ""
+	}
+
+	return user.Username
+}
+
+func (handler *AccessRequestsHandler) toResponse(accessRequest models.AccessRequest) responses.AccessRequestResponse {
+	response := responses.AccessRequestResponse{
+		ID:                accessRequest.ID,
+		Username:          handler.usernameByID(accessRequest.UserID),
+		Justification:     accessRequest.Justification,
+		Status:            accessRequest.Status,
+		RequiredApprovals: accessRequest.RequiredApprovals,
+		ApprovedBy:        []string{},
+		CreatedAt:         accessRequest.CreatedAt,
+		ExpiresAt:         accessRequest.ExpiresAt,
+		ResolvedAt:        accessRequest.ResolvedAt,
+		ResolutionReason:  accessRequest.ResolutionReason,
+	}
+
+	permission := handler.permissionsService.GetPermissionByID(accessRequest.OrganizationID, accessRequest.PermissionID)
+	if permission != nil {
+		response.PermissionName = permission.Name
+	}
+
+	for _, approverID := range accessRequest.ApprovedBy {
+		response.ApprovedBy = append(response.ApprovedBy, handler.usernameByID(approverID))
+	}
+
+	if accessRequest.DeniedBy != nil {
+		deniedBy := handler.usernameByID(*accessRequest.DeniedBy)
+		response.DeniedBy = &deniedBy
+	}
+
+	return response
+}
+
+func (handler *AccessRequestsHandler) toResponses(accessRequests []models.AccessRequest) []responses.AccessRequestResponse {
+	result := []responses.AccessRequestResponse{}
+	for _, accessRequest := range accessRequests {
+		result = append(result, handler.toResponse(accessRequest))
+	}
+
+	return result
+}
+
+func (handler *AccessRequestsHandler) CreateAccessRequest(c *gin.Context) error {
+	var body *requests.CreateAccessRequest
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := map[string]string{}
+	if body.PermissionName == "" {
+		validationErrors["permission_name"] = "El nombre del permiso no puede estar vacío"
+	}
+
+	if body.Justification == "" {
+		validationErrors["justification"] = "La justificación no puede estar vacía"
+	} else if len(body.Justification) > 200 {
+		validationErrors["justification"] = "La justificación sólo puede contener hasta 200 caracteres"
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+
+	accessRequest, err := handler.accessRequestsService.Create(currentUser.OrganizationID, currentUser.ID, body.PermissionName, body.Justification)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusCreated, handler.toResponse(*accessRequest))
+}
+
+func (handler *AccessRequestsHandler) GetAccessRequests(c *gin.Context) error {
+	status := models.AccessRequestStatus(c.Query("status"))
+
+	accessRequests := handler.accessRequestsService.GetAccessRequests(handler.organizationScope(c), status)
+
+	return handler.JSONResponse(c, http.StatusOK, handler.toResponses(accessRequests))
+}
+
+func (handler *AccessRequestsHandler) GetMyAccessRequests(c *gin.Context) error {
+	currentUser := c.MustGet("user").(models.User)
+
+	accessRequests := handler.accessRequestsService.GetAccessRequestsForUser(currentUser.ID)
+
+	return handler.JSONResponse(c, http.StatusOK, handler.toResponses(accessRequests))
+}
+
+func (handler *AccessRequestsHandler) GetAccessRequestByID(c *gin.Context) error {
+	id := c.Param("id")
+
+	accessRequestID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+
+	accessRequest := handler.accessRequestsService.GetAccessRequestByID(handler.organizationScope(c), accessRequestID)
+	if accessRequest == nil {
+		return apperror.NewErrAccessRequestNotFound()
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, handler.toResponse(*accessRequest))
+}
+
+func (handler *AccessRequestsHandler) ApproveAccessRequest(c *gin.Context) error {
+	id := c.Param("id")
+
+	accessRequestID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+
+	accessRequest, err := handler.accessRequestsService.Approve(handler.organizationScope(c), accessRequestID, currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, handler.toResponse(*accessRequest))
+}
+
+func (handler *AccessRequestsHandler) DenyAccessRequest(c *gin.Context) error {
+	id := c.Param("id")
+
+	accessRequestID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+
+	accessRequest, err := handler.accessRequestsService.Deny(handler.organizationScope(c), accessRequestID, currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, handler.toResponse(*accessRequest))
+}
+
+func NewAccessRequestsHandler(
+	logger logger.Logger,
+
+	accessRequestsService services.AccessRequestsService,
+	permissionsService services.PermissionsService,
+	usersService services.UsersService,
+) *AccessRequestsHandler {
+	return &AccessRequestsHandler{
+		BaseHandler: BaseHandler{
+			logger: logger,
+		},
+
+		accessRequestsService: accessRequestsService,
+		permissionsService:    permissionsService,
+		usersService:          usersService,
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
 	"go-crud-gin/internal/platform/authenticator"
 	"go-crud-gin/internal/platform/logger"
 	"go-crud-gin/internal/requests"
@@ -15,9 +16,10 @@ import (
 type AuthHandler struct {
 	BaseHandler
 
-	authenticator      authenticator.Authenticator
-	usersService       services.UsersService
-	permissionsService services.PermissionsService
+	authenticator        authenticator.Authenticator
+	usersService         services.UsersService
+	permissionsService   services.PermissionsService
+	organizationsService services.OrganizationsService
 }
 
 func (handler *AuthHandler) LogIn(c *gin.Context) error {
@@ -26,24 +28,19 @@ func (handler *AuthHandler) LogIn(c *gin.Context) error {
 		return err
 	}
 
-	user := handler.usersService.GetByUsername(body.Username)
-	if user == nil || user.Password != body.Password {
+	user := handler.usersService.GetByUsername(services.AllOrganizations, body.Username)
+	if user == nil || !handler.usersService.CheckPassword(*user, body.Password) {
 		return apperror.NewErrUserWrongAuthentication()
 	}
 
-	permissions := []string{}
-	for _, userPermission := range handler.permissionsService.GetPermissionsForUser(user.ID) {
-		permission := handler.permissionsService.GetPermissionByID(userPermission.PermissionID)
-		if permission == nil {
-			continue
-		}
-
-		permissions = append(permissions, permission.Name)
+	if user.Status == models.UserSuspended {
+		return apperror.NewErrUserSuspended(user.SuspensionReason, user.SuspendedUntil)
 	}
 
 	tokenStr, err := handler.authenticator.GetToken(authenticator.AuthenticatorToken{
-		UserID:      user.ID,
-		Permissions: permissions,
+		UserID:         user.PublicID,
+		OrganizationID: user.OrganizationID,
+		Permissions:    handler.permissionsService.GetPermissionNamesForUser(user.ID),
 	})
 	if err != nil {
 		return err
@@ -73,28 +70,31 @@ func (handler *AuthHandler) SignUp(c *gin.Context) error {
 		validationErrors["password"] = "La contraseña debe contener entre 8 y 40 caracteres"
 	}
 
+	// Anyone can sign up, so only into the default organization; the users of
+	// the others are invited by their administrators.
+	organizationID := services.DefaultOrganizationID
+	if body.OrganizationName != nil {
+		organization := handler.organizationsService.GetOrganizationByName(*body.OrganizationName)
+		if organization == nil || organization.ID != organizationID {
+			validationErrors["organization_name"] = "Sólo puedes registrarte en la organización default; los usuarios de otras organizaciones los invita un administrador"
+		}
+	}
+
 	if len(validationErrors) > 0 {
 		return apperror.NewErrValidation(validationErrors)
 	}
 
-	userID, err := handler.usersService.Create(body.Username, body.Password)
+	userID, err := handler.usersService.Create(organizationID, body.Username, body.Password)
 	if err != nil {
 		return err
 	}
 
-	permissions := []string{}
-	for _, userPermission := range handler.permissionsService.GetPermissionsForUser(userID) {
-		permission := handler.permissionsService.GetPermissionByID(userPermission.PermissionID)
-		if permission == nil {
-			continue
-		}
-
-		permissions = append(permissions, permission.Name)
-	}
+	user := handler.usersService.GetByID(organizationID, userID)
 
 	tokenStr, err := handler.authenticator.GetToken(authenticator.AuthenticatorToken{
-		UserID:      userID,
-		Permissions: permissions,
+		UserID:         user.PublicID,
+		OrganizationID: organizationID,
+		Permissions:    handler.permissionsService.GetPermissionNamesForUser(userID),
 	})
 	if err != nil {
 		return err
@@ -102,7 +102,7 @@ func (handler *AuthHandler) SignUp(c *gin.Context) error {
 
 	return handler.JSONResponse(c, http.StatusCreated, responses.SignUpResponse{
 		AccessToken: tokenStr,
-		UserID:      userID,
+		UserID:      user.PublicID,
 	})
 }
 
@@ -112,14 +112,16 @@ func NewAuthHandler(
 	authenticator authenticator.Authenticator,
 	usersService services.UsersService,
 	permissionsService services.PermissionsService,
+	organizationsService services.OrganizationsService,
 ) *AuthHandler {
 	return &AuthHandler{
 		BaseHandler: BaseHandler{
 			logger: logger,
 		},
 
-		authenticator:      authenticator,
-		usersService:       usersService,
-		permissionsService: permissionsService,
+		authenticator:        authenticator,
+		usersService:         usersService,
+		permissionsService:   permissionsService,
+		organizationsService: organizationsService,
 	}
 }
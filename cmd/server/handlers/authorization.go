@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/routes"
+	"go-crud-gin/internal/requests"
+	"go-crud-gin/internal/responses"
+	"go-crud-gin/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxBatchAuthorizationChecks = 100
+
+type AuthorizationHandler struct {
+	BaseHandler
+
+	authorizationService services.AuthorizationService
+	routesTable          routes.Table
+}
+
+func validateAuthorizationCheck(check requests.AuthorizationCheck, prefix string) map[string]string {
+	validationErrors := map[string]string{}
+	if check.Subject == "" {
+		validationErrors[prefix+"subject"] = "El usuario no puede estar vacío"
+	}
+
+	if check.Action == nil && check.Permissions == nil {
+		validationErrors[prefix+"permissions"] = "Debes indicar los permisos o la acción a evaluar"
+	} else if check.Action != nil && check.Permissions != nil {
+		validationErrors[prefix+"permissions"] = "No puedes indicar los permisos y la acción al mismo tiempo"
+	} else if check.Action != nil && (check.Action.Method == "" || check.Action.Path == "") {
+		validationErrors[prefix+"action"] = "La acción debe indicar el método y la ruta"
+	}
+
+	for name, value := range check.SubjectAttributes {
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			validationErrors[prefix+"subject_attributes."+name] = "El valor del atributo debe ser un texto, un número o un booleano"
+		}
+	}
+
+	return validationErrors
+}
+
+func (handler *AuthorizationHandler) check(organizationID int, check requests.AuthorizationCheck) responses.AuthorizationCheckResponse {
+	response := responses.AuthorizationCheckResponse{
+		Subject: check.Subject,
+	}
+
+	permissions := check.Permissions
+	if check.Action != nil {
+		route, ok := handler.routesTable.Match(strings.ToUpper(check.Action.Method), check.Action.Path)
+		if !ok {
+			response.AuthorizationDecision = models.AuthorizationDecision{
+				Allowed:            false,
+				MatchedPermissions: []string{},
+				Reasons: []models.AuthorizationReason{
+					{
+						Code:    models.AuthorizationReasonActionNotFound,
+						Message: fmt.Sprintf("La ruta %s %s no existe", check.Action.Method, check.Action.Path),
+					},
+				},
+			}
+
+			return response
+		}
+
+		permissions = route.Permissions
+		response.MatchedRoute = route.Path
+	}
+
+	response.AuthorizationDecision = handler.authorizationService.Check(organizationID, check.Subject, permissions, check.Resource, check.SubjectAttributes)
+
+	return response
+}
+
+func (handler *AuthorizationHandler) Check(c *gin.Context) error {
+	var body *requests.AuthorizationCheck
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := validateAuthorizationCheck(*body, "")
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, handler.check(handler.organizationScope(c), *body))
+}
+
+func (handler *AuthorizationHandler) BatchCheck(c *gin.Context) error {
+	var body *requests.BatchAuthorizationCheck
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := map[string]string{}
+	if len(body.Checks) == 0 {
+		validationErrors["checks"] = "Debes indicar al menos una consulta"
+	} else if len(body.Checks) > maxBatchAuthorizationChecks {
+		validationErrors["checks"] = fmt.Sprintf("Sólo puedes enviar hasta %d consultas", maxBatchAuthorizationChecks)
+	}
+
+	for i, check := range body.Checks {
+		for field, message := range validateAuthorizationCheck(check, fmt.Sprintf("checks[%d].", i)) {
+			validationErrors[field] = message
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	organizationID := handler.organizationScope(c)
+
+	response := responses.BatchAuthorizationCheckResponse{
+		Results: []responses.BatchAuthorizationCheckResult{},
+	}
+
+	for i, check := range body.Checks {
+		response.Results = append(response.Results, responses.BatchAuthorizationCheckResult{
+			AuthorizationCheckResponse: handler.check(organizationID, check),
+			Index:                      i,
+		})
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, response)
+}
+
+func NewAuthorizationHandler(
+	logger logger.Logger,
+
+	authorizationService services.AuthorizationService,
+	routesTable routes.Table,
+) *AuthorizationHandler {
+	return &AuthorizationHandler{
+		BaseHandler: BaseHandler{
+			logger: logger,
+		},
+
+		authorizationService: authorizationService,
+		routesTable:          routesTable,
+	}
+}
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/pagination"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,7 +15,56 @@ type BaseHandler struct {
 	logger logger.Logger
 }
 
+// organizationScope returns the organization the authenticated user can act
+// on, or services.AllOrganizations for platform administrators.
+func (handler *BaseHandler) organizationScope(c *gin.Context) int {
+	return c.MustGet("organizationID").(int)
+}
+
 func (handler *BaseHandler) JSONResponse(c *gin.Context, statusCode int, data any) error {
 	c.JSONP(statusCode, data)
 	return nil
 }
+
+// pageQuery reads the limit, cursor and sort query parameters. A sort
+// prefixed with "-" is descending.
+func (handler *BaseHandler) pageQuery(c *gin.Context, validationErrors map[string]string) pagination.Query {
+	query := pagination.Query{
+		Limit:  pagination.DefaultLimit,
+		Cursor: c.Query("cursor"),
+		Sort:   c.DefaultQuery("sort", "id"),
+	}
+
+	if value, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			validationErrors["limit"] = fmt.Sprintf("El límite debe ser un número entre 1 y %d", pagination.MaxLimit)
+		}
+
+		query.Limit = limit
+	}
+
+	if strings.HasPrefix(query.Sort, "-") {
+		query.Sort = strings.TrimPrefix(query.Sort, "-")
+		query.Descending = true
+	}
+
+	return query
+}
+
+// setPageHeaders sets X-Total-Count and, when there are more items, a Link
+// header pointing to the next page with the same query parameters.
+func (handler *BaseHandler) setPageHeaders(c *gin.Context, total int, nextCursor string) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	if nextCursor == "" {
+		return
+	}
+
+	next := url.URL{Path: c.Request.URL.Path}
+	query := c.Request.URL.Query()
+	query.Set("cursor", nextCursor)
+	next.RawQuery = query.Encode()
+
+	c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/requests"
+	"go-crud-gin/internal/services"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GroupsHandler struct {
+	BaseHandler
+
+	groupsService      services.GroupsService
+	permissionsService services.PermissionsService
+	usersService       services.UsersService
+}
+
+func (handler *GroupsHandler) CreateGroup(c *gin.Context) error {
+	var body *requests.CreateGroup
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := map[string]string{}
+	if body.GroupName == "" {
+		validationErrors["group_name"] = "El nombre del grupo no puede estar vacío"
+	} else if len(body.GroupName) < 4 || len(body.GroupName) > 50 {
+		validationErrors["group_name"] = "El nombre del grupo debe contener entre 4 y 50 caracteres"
+	}
+
+	if len(body.Description) > 100 {
+		validationErrors["description"] = "La descripción sólo puede contener hasta 100 caracteres"
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+
+	// Groups always belong to an organization, so platform administrators
+	// create them in their own.
+	organizationID := handler.organizationScope(c)
+	if organizationID == services.AllOrganizations {
+		organizationID = currentUser.OrganizationID
+	}
+
+	var parentID *int
+	if body.ParentGroupName != nil {
+		parent := handler.groupsService.GetGroupByName(organizationID, *body.ParentGroupName)
+		if parent == nil {
+			return apperror.NewErrGroupNotFound()
+		}
+
+		// The members of a subgroup inherit the permissions of its parents.
+		err := handler.permissionsService.CanManageGroup(currentUser.ID, parent.ID)
+		if err != nil {
+			return err
+		}
+
+		parentID = &parent.ID
+	}
+
+	groupID, err := handler.groupsService.Create(organizationID, body.GroupName, body.Description, parentID)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusCreated, models.Group{
+		ID:             groupID,
+		OrganizationID: organizationID,
+		Name:           body.GroupName,
+		Description:    body.Description,
+		ParentID:       parentID,
+	})
+}
+
+func (handler *GroupsHandler) GetGroups(c *gin.Context) error {
+	groups := handler.groupsService.GetGroups(handler.organizationScope(c))
+
+	return handler.JSONResponse(c, http.StatusOK, groups)
+}
+
+func (handler *GroupsHandler) GetGroupByName(c *gin.Context) error {
+	groupName := c.Param("groupName")
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, group)
+}
+
+func (handler *GroupsHandler) DeleteGroup(c *gin.Context) error {
+	groupName := c.Param("groupName")
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	err := handler.permissionsService.CanDeleteGroup(group.ID)
+	if err != nil {
+		return err
+	}
+
+	err = handler.groupsService.DeleteGroup(group.OrganizationID, group.Name)
+	if err != nil {
+		return err
+	}
+
+	err = handler.permissionsService.DeletePermissionsForGroup(group.ID)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusNoContent, nil)
+}
+
+func (handler *GroupsHandler) GetGroupMembers(c *gin.Context) error {
+	groupName := c.Param("groupName")
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	usernames := []string{}
+	for _, userID := range handler.groupsService.GetMembers(group.ID) {
+		user := handler.usersService.GetByID(group.OrganizationID, userID)
+		if user == nil {
+			continue
+		}
+
+		usernames = append(usernames, user.Username)
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, usernames)
+}
+
+func (handler *GroupsHandler) AddGroupMembers(c *gin.Context) error {
+	groupName := c.Param("groupName")
+
+	var body *requests.AddGroupMembers
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	if len(body.Usernames) == 0 {
+		return apperror.NewErrValidation(map[string]string{
+			"usernames": "Debes indicar al menos un nombre de usuario",
+		})
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	// Members get every permission of the group, so adding them is like
+	// granting those permissions.
+	err := handler.permissionsService.CanManageGroup(currentUser.ID, group.ID)
+	if err != nil {
+		return err
+	}
+
+	userIDs := []int{}
+	for _, username := range body.Usernames {
+		user := handler.usersService.GetByUsername(group.OrganizationID, username)
+		if user == nil {
+			return apperror.NewErrUserNotFound()
+		}
+
+		if user.ID != currentUser.ID {
+			err := handler.permissionsService.CanManageUser(currentUser.ID, user.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !slices.Contains(userIDs, user.ID) {
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+
+	err = handler.groupsService.AddMembers(group.ID, userIDs)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusNoContent, nil)
+}
+
+func (handler *GroupsHandler) RemoveGroupMember(c *gin.Context) error {
+	groupName := c.Param("groupName")
+	username := c.Param("username")
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	user := handler.usersService.GetByUsername(group.OrganizationID, username)
+	if user == nil {
+		return apperror.NewErrUserNotFound()
+	}
+
+	err := handler.permissionsService.CanRemoveGroupMember(group.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = handler.groupsService.RemoveMember(group.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusNoContent, nil)
+}
+
+func (handler *GroupsHandler) GetPermissionsForGroup(c *gin.Context) error {
+	groupName := c.Param("groupName")
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	permissionsNames := []string{}
+	for _, groupPermission := range handler.permissionsService.GetPermissionsForGroup(group.ID) {
+		permission := handler.permissionsService.GetPermissionByID(group.OrganizationID, groupPermission.PermissionID)
+		if permission == nil {
+			continue
+		}
+
+		permissionsNames = append(permissionsNames, permission.Name)
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, permissionsNames)
+}
+
+func (handler *GroupsHandler) GrantPermissionToGroup(c *gin.Context) error {
+	groupName := c.Param("groupName")
+	permissionName := c.Param("permissionName")
+
+	currentUser := c.MustGet("user").(models.User)
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	err := handler.permissionsService.GrantPermissionToGroup(group.OrganizationID, currentUser.ID, group.ID, permissionName)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusNoContent, nil)
+}
+
+func (handler *GroupsHandler) RevokePermissionToGroup(c *gin.Context) error {
+	groupName := c.Param("groupName")
+	permissionName := c.Param("permissionName")
+
+	group := handler.groupsService.GetGroupByName(handler.organizationScope(c), groupName)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	err := handler.permissionsService.RevokePermissionToGroup(group.OrganizationID, group.ID, permissionName)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusNoContent, nil)
+}
+
+func NewGroupsHandler(
+	logger logger.Logger,
+
+	groupsService services.GroupsService,
+	permissionsService services.PermissionsService,
+	usersService services.UsersService,
+) *GroupsHandler {
+	return &GroupsHandler{
+		BaseHandler: BaseHandler{
+			logger: logger,
+		},
+
+		groupsService:      groupsService,
+		permissionsService: permissionsService,
+		usersService:       usersService,
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/repositories"
+	"go-crud-gin/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testLogger struct{}
+
+func (testLogger) Debugf(format string, args ...any) {}
+func (testLogger) Infof(format string, args ...any)  {}
+
+type groupsFixture struct {
+	handler *GroupsHandler
+	editor  models.User
+}
+
+// newGroupsFixture creates an editor who can only write groups, an admins
+// group whose members can manage users and an unprivileged readers group.
+func newGroupsFixture(t *testing.T) groupsFixture {
+	t.Helper()
+
+	store := repositories.NewMemoryStore()
+	users := services.NewUsersService(testLogger{}, services.DefaultUsersOptions(), store.Users())
+	groups := services.NewGroupsService(testLogger{}, store.Groups())
+	permissions := services.NewPermissionsService(testLogger{}, services.DefaultPermissionsOptions(), users, groups, store.Permissions(), store.Grants())
+
+	superuserID, err := permissions.CreateBuiltIn("superuser", "Superuser")
+	if err != nil {
+		t.Fatalf("CreateBuiltIn superuser: %v", err)
+	}
+
+	for _, name := range []string{"groups_write", "users_full"} {
+		if _, err := permissions.Create(services.GlobalOrganizationID, name, name); err != nil {
+			t.Fatalf("Create %s: %v", name, err)
+		}
+	}
+
+	adminID, err := users.CreateWithPasswordHash(services.DefaultOrganizationID, "admin", "hash")
+	if err != nil {
+		t.Fatalf("Create admin: %v", err)
+	}
+
+	if err := permissions.AssignPermissionToUser(adminID, superuserID); err != nil {
+		t.Fatalf("AssignPermissionToUser: %v", err)
+	}
+
+	editorID, err := users.CreateWithPasswordHash(services.DefaultOrganizationID, "editor", "hash")
+	if err != nil {
+		t.Fatalf("Create editor: %v", err)
+	}
+
+	if err := permissions.GrantPermissionToUser(services.DefaultOrganizationID, adminID, editorID, "groups_write"); err != nil {
+		t.Fatalf("GrantPermissionToUser: %v", err)
+	}
+
+	adminsID, err := groups.Create(services.DefaultOrganizationID, "admins", "Admins", nil)
+	if err != nil {
+		t.Fatalf("Create admins: %v", err)
+	}
+
+	if err := permissions.GrantPermissionToGroup(services.DefaultOrganizationID, adminID, adminsID, "users_full"); err != nil {
+		t.Fatalf("GrantPermissionToGroup: %v", err)
+	}
+
+	if _, err := groups.Create(services.DefaultOrganizationID, "readers", "Readers", nil); err != nil {
+		t.Fatalf("Create readers: %v", err)
+	}
+
+	return groupsFixture{
+		handler: NewGroupsHandler(testLogger{}, groups, permissions, users),
+		editor:  *users.GetByID(services.DefaultOrganizationID, editorID),
+	}
+}
+
+// serve runs the request as the user and returns the code of the error the
+// handler failed with, or the status of its response.
+func serve(user models.User, handler func(c *gin.Context) error, route, method, path, body string) string {
+	gin.SetMode(gin.TestMode)
+
+	var code string
+	engine := gin.New()
+	engine.Handle(method, route, func(c *gin.Context) {
+		c.Set("user", user)
+		c.Set("organizationID", user.OrganizationID)
+
+		var appError *apperror.AppError
+		if err := handler(c); errors.As(err, &appError) {
+			code = appError.Code
+		} else if err != nil {
+			code = err.Error()
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(recorder, request)
+
+	if code != "" {
+		return code
+	}
+
+	return http.StatusText(recorder.Code)
+}
+
+func TestGroupsWriteDoesNotGrantThePermissionsOfTheGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		route  string
+		path   string
+		body   string
+		expect string
+	}{
+		{
+			name:   "join an admin group",
+			route:  "/groups/name/:groupName/members",
+			path:   "/groups/name/admins/members",
+			body:   `{"usernames": ["editor"]}`,
+			expect: apperror.ErrCannotManageGroupCode,
+		},
+		{
+			name:   "create a subgroup of an admin group",
+			route:  "/groups/",
+			path:   "/groups/",
+			body:   `{"group_name": "backdoor", "parent_group_name": "admins"}`,
+			expect: apperror.ErrCannotManageGroupCode,
+		},
+		{
+			name:   "add a more privileged user to a group",
+			route:  "/groups/name/:groupName/members",
+			path:   "/groups/name/readers/members",
+			body:   `{"usernames": ["admin"]}`,
+			expect: apperror.ErrCannotManageUserCode,
+		},
+		{
+			name:   "join an unprivileged group",
+			route:  "/groups/name/:groupName/members",
+			path:   "/groups/name/readers/members",
+			body:   `{"usernames": ["editor"]}`,
+			expect: http.StatusText(http.StatusNoContent),
+		},
+		{
+			name:   "create a subgroup of an unprivileged group",
+			route:  "/groups/",
+			path:   "/groups/",
+			body:   `{"group_name": "writers", "parent_group_name": "readers"}`,
+			expect: http.StatusText(http.StatusCreated),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture := newGroupsFixture(t)
+
+			handler := fixture.handler.AddGroupMembers
+			if test.route == "/groups/" {
+				handler = fixture.handler.CreateGroup
+			}
+
+			if got := serve(fixture.editor, handler, test.route, http.MethodPost, test.path, test.body); got != test.expect {
+				t.Fatalf("got %s, want %s", got, test.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/routes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetaHandler struct {
+	BaseHandler
+
+	routesTable  routes.Table
+	routesLoader routes.Loader
+}
+
+func (handler *MetaHandler) GetRoutes(c *gin.Context) error {
+	return handler.JSONResponse(c, http.StatusOK, handler.routesTable.Routes())
+}
+
+func (handler *MetaHandler) ReloadRoutes(c *gin.Context) error {
+	err := handler.routesLoader.Load()
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, handler.routesTable.Routes())
+}
+
+func NewMetaHandler(
+	logger logger.Logger,
+
+	routesTable routes.Table,
+	routesLoader routes.Loader,
+) *MetaHandler {
+	return &MetaHandler{
+		BaseHandler: BaseHandler{
+			logger: logger,
+		},
+
+		routesTable:  routesTable,
+		routesLoader: routesLoader,
+	}
+}
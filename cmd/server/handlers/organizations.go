@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/requests"
+	"go-crud-gin/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationsHandler struct {
+	BaseHandler
+
+	organizationsService services.OrganizationsService
+}
+
+func (handler *OrganizationsHandler) CreateOrganization(c *gin.Context) error {
+	var body *requests.CreateOrganization
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	if body.OrganizationName == "" {
+		return apperror.NewErrValidation(map[string]string{
+			"organization_name": "El nombre de la organización no puede estar vacío",
+		})
+	} else if len(body.OrganizationName) < 4 || len(body.OrganizationName) > 50 {
+		return apperror.NewErrValidation(map[string]string{
+			"organization_name": "El nombre de la organización debe contener entre 4 y 50 caracteres",
+		})
+	}
+
+	organizationID, err := handler.organizationsService.Create(body.OrganizationName)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusCreated, models.Organization{
+		ID:   organizationID,
+		Name: body.OrganizationName,
+	})
+}
+
+func (handler *OrganizationsHandler) GetOrganizations(c *gin.Context) error {
+	organizations := handler.organizationsService.GetOrganizations()
+
+	return handler.JSONResponse(c, http.StatusOK, organizations)
+}
+
+func (handler *OrganizationsHandler) GetOrganizationByName(c *gin.Context) error {
+	organizationName := c.Param("organizationName")
+
+	organization := handler.organizationsService.GetOrganizationByName(organizationName)
+	if organization == nil {
+		return apperror.NewErrOrganizationNotFound()
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, organization)
+}
+
+func NewOrganizationsHandler(
+	logger logger.Logger,
+
+	organizationsService services.OrganizationsService,
+) *OrganizationsHandler {
+	return &OrganizationsHandler{
+		BaseHandler: BaseHandler{
+			logger: logger,
+		},
+
+		organizationsService: organizationsService,
+	}
+}
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"go-crud-gin/internal/apperror"
 	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/authenticator"
 	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/routes"
+	"go-crud-gin/internal/platform/uuid"
 	"go-crud-gin/internal/requests"
+	"go-crud-gin/internal/responses"
 	"go-crud-gin/internal/services"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -18,6 +25,9 @@ type PermissionsHandler struct {
 
 	permissionsService services.PermissionsService
 	usersService       services.UsersService
+	groupsService      services.GroupsService
+	routesTable        routes.Table
+	routesLoader       routes.Loader
 }
 
 func (handler *PermissionsHandler) CreatePermission(c *gin.Context) error {
@@ -43,16 +53,20 @@ func (handler *PermissionsHandler) CreatePermission(c *gin.Context) error {
 		return apperror.NewErrValidation(validationErrors)
 	}
 
-	permissionID, err := handler.permissionsService.Create(body.PermissionName, body.Description)
+	// Permissions created by platform administrators are shared by every organization.
+	organizationID := handler.organizationScope(c)
+	if organizationID == services.AllOrganizations {
+		organizationID = services.GlobalOrganizationID
+	}
+
+	permissionID, err := handler.permissionsService.Create(organizationID, body.PermissionName, body.Description)
 	if err != nil {
 		return err
 	}
 
-	return handler.JSONResponse(c, http.StatusCreated, models.Permission{
-		ID:          permissionID,
-		Name:        body.PermissionName,
-		Description: body.Description,
-	})
+	permission := handler.permissionsService.GetPermissionByID(services.AllOrganizations, permissionID)
+
+	return handler.JSONResponse(c, http.StatusCreated, permission)
 }
 
 func (handler *PermissionsHandler) GetPermissionByID(c *gin.Context) error {
@@ -63,7 +77,23 @@ func (handler *PermissionsHandler) GetPermissionByID(c *gin.Context) error {
 		return err
 	}
 
-	permission := handler.permissionsService.GetPermissionByID(permissionID)
+	permission := handler.permissionsService.GetPermissionByID(handler.organizationScope(c), permissionID)
+	if permission == nil {
+		return apperror.NewErrPermissionNotFound()
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, permission)
+}
+
+func (handler *PermissionsHandler) GetPermissionByPublicID(c *gin.Context) error {
+	publicID := c.Param("publicId")
+	if !uuid.IsValidV7(publicID) {
+		return apperror.NewErrValidation(map[string]string{
+			"id": "El id debe ser un UUIDv7 en minúsculas",
+		})
+	}
+
+	permission := handler.permissionsService.GetPermissionByPublicID(handler.organizationScope(c), publicID)
 	if permission == nil {
 		return apperror.NewErrPermissionNotFound()
 	}
@@ -74,7 +104,7 @@ func (handler *PermissionsHandler) GetPermissionByID(c *gin.Context) error {
 func (handler *PermissionsHandler) GetPermissionByName(c *gin.Context) error {
 	permissionName := c.Param("permissionName")
 
-	permission := handler.permissionsService.GetPermissionByName(permissionName)
+	permission := handler.permissionsService.GetPermissionByName(handler.organizationScope(c), permissionName)
 	if permission == nil {
 		return apperror.NewErrPermissionNotFound()
 	}
@@ -83,15 +113,185 @@ func (handler *PermissionsHandler) GetPermissionByName(c *gin.Context) error {
 }
 
 func (handler *PermissionsHandler) GetPermissions(c *gin.Context) error {
-	permissions := handler.permissionsService.GetPermissions()
+	validationErrors := map[string]string{}
+
+	query := services.PermissionsQuery{
+		Query:        handler.pageQuery(c, validationErrors),
+		NameContains: c.Query("name_contains"),
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	page, err := handler.permissionsService.QueryPermissions(handler.organizationScope(c), query)
+	if err != nil {
+		return err
+	}
+
+	handler.setPageHeaders(c, page.Total, page.NextCursor)
+
+	return handler.JSONResponse(c, http.StatusOK, page.Items)
+}
+
+const (
+	defaultPermissionHoldersLimit = 20
+	maxPermissionHoldersLimit     = 100
+)
+
+func (handler *PermissionsHandler) GetPermissionHolders(c *gin.Context) error {
+	permissionName := c.Param("permissionName")
+
+	validationErrors := map[string]string{}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPermissionHoldersLimit)))
+	if err != nil || limit < 1 || limit > maxPermissionHoldersLimit {
+		validationErrors["limit"] = fmt.Sprintf("El límite debe ser un número entre 1 y %d", maxPermissionHoldersLimit)
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		validationErrors["offset"] = "El desplazamiento debe ser un número mayor o igual a 0"
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	organizationID := handler.organizationScope(c)
+
+	permission := handler.permissionsService.GetPermissionByName(organizationID, permissionName)
+	if permission == nil {
+		return apperror.NewErrPermissionNotFound()
+	}
+
+	// Global permissions are held by users of every organization, so the
+	// holders are filtered by the scope of the authenticated user.
+	holders := []responses.PermissionHolderResponse{}
+	for _, holder := range handler.permissionsService.GetPermissionHolders(permission.ID) {
+		user := handler.usersService.GetByID(organizationID, holder.UserID)
+		if user == nil {
+			continue
+		}
+
+		inheritedFrom := []string{}
+		for _, groupID := range holder.GroupIDs {
+			group := handler.groupsService.GetGroupByID(services.AllOrganizations, groupID)
+			if group == nil {
+				continue
+			}
+
+			inheritedFrom = append(inheritedFrom, group.Name)
+		}
+
+		holders = append(holders, responses.PermissionHolderResponse{
+			ID:            user.PublicID,
+			Username:      user.Username,
+			Direct:        holder.Direct,
+			InheritedFrom: inheritedFrom,
+		})
+	}
+
+	response := responses.PermissionHoldersResponse{
+		Total:  len(holders),
+		Limit:  limit,
+		Offset: offset,
+		Users:  []responses.PermissionHolderResponse{},
+	}
+
+	if offset < len(holders) {
+		response.Users = holders[offset:min(offset+limit, len(holders))]
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, response)
+}
+
+// requiredByRoutes reports whether the routes config requires the permission
+// name, which the config would then reject on the next load if it were
+// deleted. Only global permissions can be required by a route.
+func (handler *PermissionsHandler) requiredByRoutes(c *gin.Context, name string) bool {
+	permission := handler.permissionsService.GetPermissionByName(handler.organizationScope(c), name)
+
+	return permission != nil && permission.OrganizationID == services.GlobalOrganizationID && handler.routesTable.RequiresPermission(permission.Name)
+}
+
+func (handler *PermissionsHandler) UpdatePermission(c *gin.Context) error {
+	permissionName := c.Param("permissionName")
+
+	var body *requests.UpdatePermission
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := map[string]string{}
+	if body.PermissionName == nil && body.Description == nil {
+		validationErrors["permission_name"] = "Debes indicar el nuevo nombre o la nueva descripción"
+	}
 
-	return handler.JSONResponse(c, http.StatusOK, permissions)
+	if body.PermissionName != nil {
+		if *body.PermissionName == "" {
+			validationErrors["permission_name"] = "El nombre del permiso no puede estar vacío"
+		} else if len(*body.PermissionName) < 4 || len(*body.PermissionName) > 50 {
+			validationErrors["permission_name"] = "El nombre del permiso debe contener entre 4 y 50 caracteres"
+		}
+	}
+
+	if body.Description != nil {
+		if *body.Description == "" {
+			validationErrors["description"] = "La descripción no puede estar vacía"
+		} else if len(*body.Description) > 100 {
+			validationErrors["description"] = "La descripción sólo puede contener hasta 100 caracteres"
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	permission, err := handler.permissionsService.UpdatePermission(handler.organizationScope(c), permissionName, body.PermissionName, body.Description)
+	if err != nil {
+		return err
+	}
+
+	response := responses.UpdatePermissionResponse{
+		Permission: *permission,
+		Warnings:   []string{},
+	}
+
+	if body.PermissionName != nil && !strings.EqualFold(permissionName, permission.Name) {
+		// Only global permissions can be required by a route.
+		if permission.OrganizationID == services.GlobalOrganizationID {
+			response.RoutesUpdated, err = handler.routesLoader.RenamePermission(permissionName, permission.Name)
+			if err != nil {
+				// The routes would otherwise require a permission that no
+				// longer exists.
+				oldName := permissionName
+				if _, rollbackErr := handler.permissionsService.UpdatePermission(handler.organizationScope(c), permission.Name, &oldName, nil); rollbackErr != nil {
+					return errors.Join(err, rollbackErr)
+				}
+
+				return err
+			}
+		}
+
+		response.Warnings = append(response.Warnings, fmt.Sprintf(
+			"Los tokens emitidos antes del cambio siguen incluyendo el permiso %s hasta que expiren (1 hora); sus usuarios deben iniciar sesión de nuevo para usar %s",
+			permissionName,
+			permission.Name,
+		))
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, response)
 }
 
 func (handler *PermissionsHandler) DeletePermission(c *gin.Context) error {
 	permissionName := c.Param("permissionName")
 
-	err := handler.permissionsService.DeletePermission(permissionName)
+	if handler.requiredByRoutes(c, permissionName) {
+		return apperror.NewErrPermissionRequiredByRoutes()
+	}
+
+	err := handler.permissionsService.DeletePermission(handler.organizationScope(c), permissionName)
 	if err != nil {
 		return err
 	}
@@ -102,22 +302,12 @@ func (handler *PermissionsHandler) DeletePermission(c *gin.Context) error {
 func (handler *PermissionsHandler) GetPermissionsForUser(c *gin.Context) error {
 	username := c.Param("username")
 
-	user := handler.usersService.GetByUsername(username)
+	user := handler.usersService.GetByUsername(handler.organizationScope(c), username)
 	if user == nil {
 		return apperror.NewErrUserNotFound()
 	}
 
-	userPermissions := handler.permissionsService.GetPermissionsForUser(user.ID)
-
-	permissionsNames := []string{}
-	for _, userPermission := range userPermissions {
-		permission := handler.permissionsService.GetPermissionByID(userPermission.PermissionID)
-		if permission == nil {
-			continue
-		}
-
-		permissionsNames = append(permissionsNames, permission.Name)
-	}
+	permissionsNames := handler.permissionsService.GetPermissionNamesForUser(user.ID)
 
 	return handler.JSONResponse(c, http.StatusOK, permissionsNames)
 }
@@ -126,12 +316,14 @@ func (handler *PermissionsHandler) GrantPermissionToUser(c *gin.Context) error {
 	username := c.Param("username")
 	permissionName := c.Param("permissionName")
 
-	user := handler.usersService.GetByUsername(username)
+	currentUser := c.MustGet("user").(models.User)
+
+	user := handler.usersService.GetByUsername(handler.organizationScope(c), username)
 	if user == nil {
 		return apperror.NewErrUserNotFound()
 	}
 
-	err := handler.permissionsService.GrantPermissionToUser(user.ID, permissionName)
+	err := handler.permissionsService.GrantPermissionToUser(user.OrganizationID, currentUser.ID, user.ID, permissionName)
 	if err != nil {
 		return err
 	}
@@ -148,12 +340,12 @@ func (handler *PermissionsHandler) RevokePermissionToUser(c *gin.Context) error
 		return apperror.NewErrCannotRevokeUserPermission()
 	}
 
-	user := handler.usersService.GetByUsername(username)
+	user := handler.usersService.GetByUsername(handler.organizationScope(c), username)
 	if user == nil {
 		return apperror.NewErrUserNotFound()
 	}
 
-	err := handler.permissionsService.RevokePermissionToUser(user.ID, permissionName)
+	err := handler.permissionsService.RevokePermissionToUser(user.OrganizationID, user.ID, permissionName)
 	if err != nil {
 		return err
 	}
@@ -161,11 +353,142 @@ func (handler *PermissionsHandler) RevokePermissionToUser(c *gin.Context) error
 	return handler.JSONResponse(c, http.StatusNoContent, nil)
 }
 
+// userPermissionPath is the route that grants (POST) or revokes (DELETE) one
+// permission of a user. The bulk operations require what the routes config
+// declares for it, so both ways of granting stay behind the same permissions.
+const userPermissionPath = "/users/username/:username/permission/:permissionName/"
+
+// checkRoutePermissions returns the error the route would fail with for a user
+// holding userPermissions, or nil if the user holds any of its permissions.
+func (handler *PermissionsHandler) checkRoutePermissions(userPermissions []string, method, path string) *apperror.AppError {
+	route, ok := handler.routesTable.Get(method, path)
+	if !ok {
+		return apperror.NewErrForbidden(nil, nil)
+	}
+
+	if authenticator.HasAnyPermission(userPermissions, route.Permissions) {
+		return nil
+	}
+
+	missing := []string{}
+	for _, permission := range route.Permissions {
+		if !slices.Contains(userPermissions, permission) {
+			missing = append(missing, permission)
+		}
+	}
+
+	return apperror.NewErrForbidden(route.Permissions, missing)
+}
+
+// BulkUserPermissions grants and revokes several permissions at once. Nothing
+// is applied unless every operation is valid.
+func (handler *PermissionsHandler) BulkUserPermissions(c *gin.Context) error {
+	var body *requests.BulkUserPermissions
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	if len(body.Operations) == 0 {
+		return apperror.NewErrValidation(map[string]string{
+			"operations": "Debes indicar al menos una operación",
+		})
+	} else if len(body.Operations) > 500 {
+		return apperror.NewErrValidation(map[string]string{
+			"operations": "Sólo puedes enviar hasta 500 operaciones",
+		})
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+	currentPermissions := handler.permissionsService.GetPermissionNamesForUser(currentUser.ID)
+
+	results := make([]responses.BulkUserPermissionResult, len(body.Operations))
+	operations := []models.UserPermissionOperation{}
+	operationIndexes := []int{}
+	for i, item := range body.Operations {
+		results[i] = responses.BulkUserPermissionResult{
+			Index:          i,
+			Username:       item.Username,
+			PermissionName: item.PermissionName,
+			Action:         item.Action,
+		}
+
+		action := models.PermissionAction(item.Action)
+
+		method := http.MethodPost
+		if action == models.PermissionActionRevoke {
+			method = http.MethodDelete
+		}
+
+		var err *apperror.AppError
+		user := handler.usersService.GetByUsername(handler.organizationScope(c), item.Username)
+		if action != models.PermissionActionGrant && action != models.PermissionActionRevoke {
+			err = apperror.NewErrValidation(map[string]string{
+				"action": "La acción debe ser grant o revoke",
+			})
+		} else if forbidden := handler.checkRoutePermissions(currentPermissions, method, userPermissionPath); forbidden != nil {
+			err = forbidden
+		} else if user == nil {
+			err = apperror.NewErrUserNotFound()
+		} else if action == models.PermissionActionRevoke && user.ID == currentUser.ID {
+			err = apperror.NewErrCannotRevokeUserPermission()
+		}
+
+		if err != nil {
+			results[i].Error = err
+			continue
+		}
+
+		operations = append(operations, models.UserPermissionOperation{
+			OrganizationID: user.OrganizationID,
+			UserID:         user.ID,
+			PermissionName: item.PermissionName,
+			Action:         action,
+		})
+		operationIndexes = append(operationIndexes, i)
+	}
+
+	dryRun := body.DryRun || len(operations) < len(body.Operations)
+	errs, applied := handler.permissionsService.ApplyUserPermissionOperations(currentUser.ID, operations, dryRun)
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		var appErr *apperror.AppError
+		if !errors.As(err, &appErr) {
+			appErr = apperror.NewErrInternalServerError(err)
+		}
+
+		results[operationIndexes[i]].Error = appErr
+	}
+
+	valid := true
+	for i := range results {
+		results[i].Success = results[i].Error == nil
+		valid = valid && results[i].Success
+	}
+
+	statusCode := http.StatusOK
+	if !valid {
+		statusCode = http.StatusBadRequest
+	}
+
+	return handler.JSONResponse(c, statusCode, responses.BulkUserPermissionsResponse{
+		DryRun:  body.DryRun,
+		Applied: applied,
+		Results: results,
+	})
+}
+
 func NewPermissionsHandler(
 	logger logger.Logger,
 
 	permissionsService services.PermissionsService,
 	usersService services.UsersService,
+	groupsService services.GroupsService,
+	routesTable routes.Table,
+	routesLoader routes.Loader,
 ) *PermissionsHandler {
 	return &PermissionsHandler{
 		BaseHandler: BaseHandler{
@@ -174,5 +497,8 @@ func NewPermissionsHandler(
 
 		permissionsService: permissionsService,
 		usersService:       usersService,
+		groupsService:      groupsService,
+		routesTable:        routesTable,
+		routesLoader:       routesLoader,
 	}
 }
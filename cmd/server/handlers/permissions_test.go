@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"go-crud-gin/internal/platform/routes"
+	"go-crud-gin/internal/repositories"
+	"go-crud-gin/internal/services"
+	"net/http"
+	"testing"
+)
+
+func TestBulkUserPermissionsRequireWhatTheRoutesConfigDeclares(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	users := services.NewUsersService(testLogger{}, services.DefaultUsersOptions(), store.Users())
+	groups := services.NewGroupsService(testLogger{}, store.Groups())
+	permissions := services.NewPermissionsService(testLogger{}, services.DefaultPermissionsOptions(), users, groups, store.Permissions(), store.Grants())
+
+	// The routes config moved the single grant route to another permission.
+	table := routes.NewTable()
+	table.Replace([]routes.Route{
+		{Method: http.MethodPost, Path: userPermissionPath, Permissions: []string{"user_grantors"}, AuthRequired: true},
+		{Method: http.MethodDelete, Path: userPermissionPath, Permissions: []string{"revoke_permission"}, AuthRequired: true},
+	})
+
+	handler := NewPermissionsHandler(testLogger{}, permissions, users, groups, table, nil)
+
+	for _, name := range []string{"user_grantors", "grant_permission", "reports_read"} {
+		if _, err := permissions.Create(services.GlobalOrganizationID, name, name); err != nil {
+			t.Fatalf("Create %s: %v", name, err)
+		}
+	}
+
+	grantors := map[string]string{
+		"grantor": "user_grantors",
+		"legacy":  "grant_permission",
+	}
+	for username, permissionName := range grantors {
+		userID, err := users.CreateWithPasswordHash(services.DefaultOrganizationID, username, "hash")
+		if err != nil {
+			t.Fatalf("Create %s: %v", username, err)
+		}
+
+		for _, name := range []string{permissionName, "reports_read"} {
+			permission := permissions.GetPermissionByName(services.GlobalOrganizationID, name)
+			if err := permissions.AssignPermissionToUser(userID, permission.ID); err != nil {
+				t.Fatalf("AssignPermissionToUser: %v", err)
+			}
+		}
+	}
+
+	if _, err := users.CreateWithPasswordHash(services.DefaultOrganizationID, "target", "hash"); err != nil {
+		t.Fatalf("Create target: %v", err)
+	}
+
+	tests := []struct {
+		username string
+		expect   string
+	}{
+		{username: "legacy", expect: http.StatusText(http.StatusBadRequest)},
+		{username: "grantor", expect: http.StatusText(http.StatusOK)},
+	}
+
+	body := `{"operations": [{"username": "target", "permission_name": "reports_read", "action": "grant"}]}`
+	for _, test := range tests {
+		t.Run(test.username, func(t *testing.T) {
+			user := users.GetByUsername(services.DefaultOrganizationID, test.username)
+			if got := serve(*user, handler.BulkUserPermissions, "/permissions/bulk", http.MethodPost, "/permissions/bulk", body); got != test.expect {
+				t.Fatalf("got %s, want %s", got, test.expect)
+			}
+		})
+	}
+}
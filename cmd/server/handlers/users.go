@@ -1,27 +1,92 @@
 package handlers
 
 import (
+	"fmt"
 	"go-crud-gin/internal/apperror"
 	"go-crud-gin/internal/models"
 	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/uuid"
+	"go-crud-gin/internal/requests"
 	"go-crud-gin/internal/services"
 	"net/http"
+	"net/mail"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
 )
 
+var attributeNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{1,29}$`)
+
 type UsersHandler struct {
 	BaseHandler
 
-	usersService services.UsersService
+	usersService          services.UsersService
+	permissionsService    services.PermissionsService
+	groupsService         services.GroupsService
+	userAttributesService services.UserAttributesService
+}
+
+// normalizeEmail returns the lowercase address, or false when email is not a
+// bare address like user@example.com.
+func normalizeEmail(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", false
+	}
+
+	return strings.ToLower(email), true
+}
+
+// normalizeLocale returns the canonical BCP 47 form of locale, like es-CO.
+func normalizeLocale(locale string) (string, bool) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil {
+		return "", false
+	}
+
+	return tag.String(), true
 }
 
 func (handler *UsersHandler) GetUsers(c *gin.Context) error {
-	users := handler.usersService.GetUsers()
+	validationErrors := map[string]string{}
+
+	query := services.UsersQuery{
+		Query:          handler.pageQuery(c, validationErrors),
+		UsernamePrefix: c.Query("username_prefix"),
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	organizationID := handler.organizationScope(c)
+
+	if permissionName, ok := c.GetQuery("has_permission"); ok {
+		permission := handler.permissionsService.GetPermissionByName(organizationID, permissionName)
+		if permission == nil {
+			return apperror.NewErrPermissionNotFound()
+		}
+
+		query.UserIDs = []int{}
+		for _, holder := range handler.permissionsService.GetPermissionHolders(permission.ID) {
+			query.UserIDs = append(query.UserIDs, holder.UserID)
+		}
+	}
+
+	page, err := handler.usersService.QueryUsers(organizationID, query)
+	if err != nil {
+		return err
+	}
 
-	return handler.JSONResponse(c, http.StatusOK, users)
+	handler.setPageHeaders(c, page.Total, page.NextCursor)
+
+	return handler.JSONResponse(c, http.StatusOK, page.Items)
 }
 
 func (handler *UsersHandler) GetUserByID(c *gin.Context) error {
@@ -32,7 +97,23 @@ func (handler *UsersHandler) GetUserByID(c *gin.Context) error {
 		return err
 	}
 
-	user := handler.usersService.GetByID(userID)
+	user := handler.usersService.GetByID(handler.organizationScope(c), userID)
+	if user == nil {
+		return apperror.NewErrUserNotFound()
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, user)
+}
+
+func (handler *UsersHandler) GetUserByPublicID(c *gin.Context) error {
+	publicID := c.Param("publicId")
+	if !uuid.IsValidV7(publicID) {
+		return apperror.NewErrValidation(map[string]string{
+			"id": "El id debe ser un UUIDv7 en minúsculas",
+		})
+	}
+
+	user := handler.usersService.GetByPublicID(handler.organizationScope(c), publicID)
 	if user == nil {
 		return apperror.NewErrUserNotFound()
 	}
@@ -43,7 +124,7 @@ func (handler *UsersHandler) GetUserByID(c *gin.Context) error {
 func (handler *UsersHandler) GetUserByUsername(c *gin.Context) error {
 	username := c.Param("username")
 
-	user := handler.usersService.GetByUsername(username)
+	user := handler.usersService.GetByUsername(handler.organizationScope(c), username)
 	if user == nil {
 		return apperror.NewErrUserNotFound()
 	}
@@ -51,6 +132,104 @@ func (handler *UsersHandler) GetUserByUsername(c *gin.Context) error {
 	return handler.JSONResponse(c, http.StatusOK, user)
 }
 
+func (handler *UsersHandler) UpdateUser(c *gin.Context) error {
+	username := c.Param("username")
+
+	var body *requests.UpdateUser
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := map[string]string{}
+	if body.Username == nil && body.Password == nil && body.DisplayName == nil && body.Email == nil && body.Locale == nil && body.Attributes == nil {
+		validationErrors["username"] = "Debes indicar al menos un campo a modificar"
+	}
+
+	if body.Username != nil {
+		if *body.Username == "" {
+			validationErrors["username"] = "El nombre de usuario no puede estar vacío"
+		} else if len(*body.Username) < 4 || len(*body.Username) > 15 {
+			validationErrors["username"] = "El nombre de usuario debe contener entre 4 y 15 caracteres"
+		}
+	}
+
+	if body.Password != nil {
+		if *body.Password == "" {
+			validationErrors["password"] = "La contraseña no puede estar vacía"
+		} else if len(*body.Password) < 8 || len(*body.Password) > 40 {
+			validationErrors["password"] = "La contraseña debe contener entre 8 y 40 caracteres"
+		}
+	}
+
+	if body.DisplayName != nil && len(*body.DisplayName) > 50 {
+		validationErrors["display_name"] = "El nombre para mostrar sólo puede contener hasta 50 caracteres"
+	}
+
+	// An empty email or locale removes it from the user.
+	if body.Email != nil && *body.Email != "" {
+		email, ok := normalizeEmail(*body.Email)
+		if !ok {
+			validationErrors["email"] = "El correo electrónico no es válido"
+		}
+
+		body.Email = &email
+	}
+
+	if body.Locale != nil && *body.Locale != "" {
+		locale, ok := normalizeLocale(*body.Locale)
+		if !ok {
+			validationErrors["locale"] = "El idioma debe ser una etiqueta BCP 47, como es o es-CO"
+		}
+
+		body.Locale = &locale
+	}
+
+	organizationID := handler.organizationScope(c)
+
+	// Whoever sets the password or username of another user can log in as
+	// them, so they must be able to grant every permission that user has.
+	currentUser := c.MustGet("user").(models.User)
+	if (body.Password != nil || body.Username != nil) && !strings.EqualFold(currentUser.Username, username) {
+		user := handler.usersService.GetByUsername(organizationID, username)
+		if user == nil {
+			return apperror.NewErrUserNotFound()
+		}
+
+		if err := handler.permissionsService.CanManageUser(currentUser.ID, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if body.Attributes != nil {
+		user := handler.usersService.GetByUsername(organizationID, username)
+		if user == nil {
+			return apperror.NewErrUserNotFound()
+		}
+
+		for field, message := range handler.userAttributesService.Validate(user.OrganizationID, body.Attributes) {
+			validationErrors[field] = message
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	user, err := handler.usersService.UpdateUser(organizationID, username, models.UserUpdate{
+		Username:    body.Username,
+		Password:    body.Password,
+		DisplayName: body.DisplayName,
+		Email:       body.Email,
+		Locale:      body.Locale,
+		Attributes:  body.Attributes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, user)
+}
+
 func (handler *UsersHandler) DeleteUser(c *gin.Context) error {
 	username := c.Param("username")
 
@@ -59,7 +238,161 @@ func (handler *UsersHandler) DeleteUser(c *gin.Context) error {
 		return apperror.NewErrUserNotDeletable()
 	}
 
-	err := handler.usersService.DeleteUser(username)
+	user := handler.usersService.GetByUsername(handler.organizationScope(c), username)
+	if user == nil {
+		return apperror.NewErrUserNotFound()
+	}
+
+	err := handler.permissionsService.CanRemoveUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = handler.usersService.DeleteUser(user.OrganizationID, user.Username)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusNoContent, nil)
+}
+
+func (handler *UsersHandler) RestoreUser(c *gin.Context) error {
+	username := c.Param("username")
+
+	user, err := handler.usersService.RestoreUser(handler.organizationScope(c), username)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, user)
+}
+
+func (handler *UsersHandler) SuspendUser(c *gin.Context) error {
+	username := c.Param("username")
+
+	// The body is optional, for suspensions without reason or end.
+	body := &requests.SuspendUser{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBind(&body); err != nil {
+			return err
+		}
+	}
+
+	validationErrors := map[string]string{}
+	if len(body.Reason) > 200 {
+		validationErrors["reason"] = "El motivo sólo puede contener hasta 200 caracteres"
+	}
+
+	if body.Until != nil && !body.Until.After(time.Now()) {
+		validationErrors["until"] = "La fecha de fin de la suspensión debe ser futura"
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+	if strings.EqualFold(currentUser.Username, username) {
+		return apperror.NewErrUserNotSuspendable()
+	}
+
+	user := handler.usersService.GetByUsername(handler.organizationScope(c), username)
+	if user == nil {
+		return apperror.NewErrUserNotFound()
+	}
+
+	// A suspended user does not hold its permissions, like a deleted one.
+	err := handler.permissionsService.CanRemoveUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	user, err = handler.usersService.SuspendUser(user.OrganizationID, user.Username, body.Reason, body.Until)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, user)
+}
+
+func (handler *UsersHandler) ReactivateUser(c *gin.Context) error {
+	username := c.Param("username")
+
+	user, err := handler.usersService.ReactivateUser(handler.organizationScope(c), username)
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, user)
+}
+
+func (handler *UsersHandler) GetUserAttributes(c *gin.Context) error {
+	definitions := handler.userAttributesService.GetDefinitions(handler.organizationScope(c))
+
+	return handler.JSONResponse(c, http.StatusOK, definitions)
+}
+
+func (handler *UsersHandler) SetUserAttribute(c *gin.Context) error {
+	attributeName := c.Param("attributeName")
+
+	var body *requests.SetUserAttribute
+	if err := c.ShouldBind(&body); err != nil {
+		return err
+	}
+
+	validationErrors := map[string]string{}
+	if !attributeNameRegexp.MatchString(attributeName) {
+		validationErrors["name"] = "El nombre del atributo debe contener entre 2 y 30 letras minúsculas, números o guiones bajos, empezando por una letra"
+	}
+
+	attributeType := models.UserAttributeType(body.Type)
+	if attributeType != models.UserAttributeString && attributeType != models.UserAttributeNumber && attributeType != models.UserAttributeBoolean {
+		validationErrors["type"] = fmt.Sprintf(
+			"El tipo debe ser %s, %s o %s",
+			models.UserAttributeString,
+			models.UserAttributeNumber,
+			models.UserAttributeBoolean,
+		)
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	// Attributes defined by platform administrators are shared by every organization.
+	organizationID := handler.organizationScope(c)
+	if organizationID == services.AllOrganizations {
+		organizationID = services.GlobalOrganizationID
+	}
+
+	definition, err := handler.userAttributesService.SetDefinition(models.UserAttributeDefinition{
+		OrganizationID: organizationID,
+		Name:           attributeName,
+		Type:           attributeType,
+		Required:       body.Required,
+		AllowedValues:  body.AllowedValues,
+	})
+	if err != nil {
+		return err
+	}
+
+	return handler.JSONResponse(c, http.StatusOK, definition)
+}
+
+func (handler *UsersHandler) DeleteUserAttribute(c *gin.Context) error {
+	attributeName := c.Param("attributeName")
+
+	definition, err := handler.userAttributesService.DeleteDefinition(handler.organizationScope(c), attributeName)
+	if err != nil {
+		return err
+	}
+
+	organizationID := definition.OrganizationID
+	if organizationID == services.GlobalOrganizationID {
+		organizationID = services.AllOrganizations
+	}
+
+	err = handler.usersService.RemoveAttribute(organizationID, definition.Name)
 	if err != nil {
 		return err
 	}
@@ -71,12 +404,18 @@ func NewUsersHandler(
 	logger logger.Logger,
 
 	usersService services.UsersService,
+	permissionsService services.PermissionsService,
+	groupsService services.GroupsService,
+	userAttributesService services.UserAttributesService,
 ) *UsersHandler {
 	return &UsersHandler{
 		BaseHandler: BaseHandler{
 			logger: logger,
 		},
 
-		usersService: usersService,
+		usersService:          usersService,
+		permissionsService:    permissionsService,
+		groupsService:         groupsService,
+		userAttributesService: userAttributesService,
 	}
 }
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/requests"
+	"go-crud-gin/internal/responses"
+	"go-crud-gin/internal/services"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxImportedUsers = 5000
+
+	// csvPermissionsSeparator separates the permissions of an user in a CSV cell.
+	csvPermissionsSeparator = ";"
+)
+
+var usersCSVHeader = []string{
+	"username",
+	"organization_name",
+	"password_hash",
+	"invite",
+	"email",
+	"display_name",
+	"locale",
+	"permissions",
+	"attributes",
+}
+
+type UsersImportHandler struct {
+	BaseHandler
+
+	usersImportService services.UsersImportService
+	permissionsService services.PermissionsService
+}
+
+// readUsersCSV reads the rows of a CSV with a header naming some of the
+// columns of usersCSVHeader, in any order. Rows that cannot be read get an
+// error at their index.
+func readUsersCSV(reader io.Reader) ([]models.UserImportRow, map[int]*apperror.AppError, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, nil, apperror.NewErrValidation(map[string]string{
+			"header": "El archivo CSV debe tener una fila de encabezado",
+		})
+	}
+
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(usersCSVHeader, header[i]) {
+			return nil, nil, apperror.NewErrValidation(map[string]string{
+				"header": fmt.Sprintf("La columna %s no existe, las columnas válidas son %s", column, strings.Join(usersCSVHeader, ", ")),
+			})
+		}
+	}
+
+	if !slices.Contains(header, "username") {
+		return nil, nil, apperror.NewErrValidation(map[string]string{
+			"header": "El archivo CSV debe tener la columna username",
+		})
+	}
+
+	rows := []models.UserImportRow{}
+	rowErrors := map[int]*apperror.AppError{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, apperror.NewErrValidation(map[string]string{
+				"csv": fmt.Sprintf("El archivo CSV no es válido: %v", err),
+			})
+		}
+
+		row := models.UserImportRow{Permissions: []string{}}
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+
+			value = strings.TrimSpace(value)
+			switch header[i] {
+			case "username":
+				row.Username = value
+			case "organization_name":
+				row.OrganizationName = value
+			case "password_hash":
+				row.PasswordHash = value
+			case "invite":
+				if value == "" {
+					continue
+				}
+
+				invite, err := strconv.ParseBool(value)
+				if err != nil {
+					rowErrors[len(rows)] = apperror.NewErrValidation(map[string]string{
+						"invite": "El valor de invite debe ser true o false",
+					})
+				}
+
+				row.Invite = invite
+			case "email":
+				row.Email = value
+			case "display_name":
+				row.DisplayName = value
+			case "locale":
+				row.Locale = value
+			case "permissions":
+				for _, permissionName := range strings.Split(value, csvPermissionsSeparator) {
+					if permissionName = strings.TrimSpace(permissionName); permissionName != "" {
+						row.Permissions = append(row.Permissions, permissionName)
+					}
+				}
+			case "attributes":
+				if value == "" {
+					continue
+				}
+
+				if err := json.Unmarshal([]byte(value), &row.Attributes); err != nil {
+					rowErrors[len(rows)] = apperror.NewErrValidation(map[string]string{
+						"attributes": "Los atributos deben ser un objeto JSON",
+					})
+				}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// validateImportRow checks the format of a row and normalizes its email and
+// locale. Existence and uniqueness are checked by the service.
+func validateImportRow(row *models.UserImportRow) *apperror.AppError {
+	validationErrors := map[string]string{}
+	if row.Username == "" {
+		validationErrors["username"] = "El nombre de usuario no puede estar vacío"
+	} else if len(row.Username) < 4 || len(row.Username) > 15 {
+		validationErrors["username"] = "El nombre de usuario debe contener entre 4 y 15 caracteres"
+	}
+
+	if row.Invite && row.PasswordHash != "" {
+		validationErrors["password_hash"] = "No puedes indicar el hash de la contraseña de un usuario invitado"
+	} else if !row.Invite && row.PasswordHash == "" {
+		validationErrors["password_hash"] = "Debes indicar el hash de la contraseña o invitar al usuario"
+	} else if row.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(row.PasswordHash)); err != nil {
+			validationErrors["password_hash"] = "El hash de la contraseña debe ser un hash de bcrypt"
+		}
+	}
+
+	if row.Email != "" {
+		email, ok := normalizeEmail(row.Email)
+		if !ok {
+			validationErrors["email"] = "El correo electrónico no es válido"
+		}
+
+		row.Email = email
+	}
+
+	if row.Locale != "" {
+		locale, ok := normalizeLocale(row.Locale)
+		if !ok {
+			validationErrors["locale"] = "El idioma debe ser una etiqueta BCP 47, como es o es-CO"
+		}
+
+		row.Locale = locale
+	}
+
+	if len(row.DisplayName) > 50 {
+		validationErrors["display_name"] = "El nombre para mostrar sólo puede contener hasta 50 caracteres"
+	}
+
+	if row.Permissions == nil {
+		row.Permissions = []string{}
+	}
+
+	for i, permissionName := range row.Permissions {
+		if slices.ContainsFunc(row.Permissions[:i], func(previous string) bool {
+			return strings.EqualFold(previous, permissionName)
+		}) {
+			validationErrors["permissions"] = fmt.Sprintf("El permiso %s está repetido", permissionName)
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return apperror.NewErrValidation(validationErrors)
+	}
+
+	return nil
+}
+
+// ImportUsers reads a CSV when the Content-Type is text/csv and a JSON body
+// otherwise. Nothing is created unless every row is valid.
+func (handler *UsersImportHandler) ImportUsers(c *gin.Context) error {
+	dryRun := c.Query("dry_run") == "true"
+
+	var rows []models.UserImportRow
+	rowErrors := map[int]*apperror.AppError{}
+	if c.ContentType() == "text/csv" {
+		var err error
+		rows, rowErrors, err = readUsersCSV(c.Request.Body)
+		if err != nil {
+			return err
+		}
+	} else {
+		var body *requests.ImportUsers
+		if err := c.ShouldBind(&body); err != nil {
+			return err
+		}
+
+		rows = body.Users
+	}
+
+	if len(rows) == 0 {
+		return apperror.NewErrValidation(map[string]string{
+			"users": "Debes indicar al menos un usuario",
+		})
+	} else if len(rows) > maxImportedUsers {
+		return apperror.NewErrValidation(map[string]string{
+			"users": fmt.Sprintf("Sólo puedes importar hasta %d usuarios", maxImportedUsers),
+		})
+	}
+
+	currentUser := c.MustGet("user").(models.User)
+	canGrant := slices.Contains(handler.permissionsService.GetPermissionNamesForUser(currentUser.ID), "grant_permission")
+
+	results := make([]responses.UserImportResult, len(rows))
+	validRows := []models.UserImportRow{}
+	validRowIndexes := []int{}
+	for i := range rows {
+		results[i] = responses.UserImportResult{
+			Row:      i + 1,
+			Username: rows[i].Username,
+		}
+
+		if rowErrors[i] == nil {
+			rowErrors[i] = validateImportRow(&rows[i])
+		}
+
+		if rowErrors[i] == nil && len(rows[i].Permissions) > 0 && !canGrant {
+			rowErrors[i] = apperror.NewErrForbidden([]string{"grant_permission"}, []string{"grant_permission"})
+		}
+
+		if rowErrors[i] != nil {
+			results[i].Error = rowErrors[i]
+			continue
+		}
+
+		validRows = append(validRows, rows[i])
+		validRowIndexes = append(validRowIndexes, i)
+	}
+
+	// The remaining rows are still checked against the existing users, but
+	// nothing is created when some row is not valid.
+	importResults, applied := handler.usersImportService.Import(
+		handler.organizationScope(c),
+		currentUser.ID,
+		validRows,
+		dryRun || len(validRows) < len(rows),
+	)
+
+	for i, importResult := range importResults {
+		index := validRowIndexes[i]
+		results[index].TemporaryPassword = importResult.TemporaryPassword
+		if importResult.Error == nil {
+			continue
+		}
+
+		var appErr *apperror.AppError
+		if !errors.As(importResult.Error, &appErr) {
+			appErr = apperror.NewErrInternalServerError(importResult.Error)
+		}
+
+		results[index].Error = appErr
+	}
+
+	valid := true
+	for i := range results {
+		results[i].Success = results[i].Error == nil
+		valid = valid && results[i].Success
+	}
+
+	statusCode := http.StatusOK
+	if !valid {
+		statusCode = http.StatusBadRequest
+	}
+
+	return handler.JSONResponse(c, statusCode, responses.UsersImportResponse{
+		DryRun:  dryRun,
+		Applied: applied,
+		Results: results,
+	})
+}
+
+// ExportUsers writes the users in the format ImportUsers reads: a CSV with
+// ?format=csv, or a JSON body that can be sent back as is.
+func (handler *UsersImportHandler) ExportUsers(c *gin.Context) error {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		return apperror.NewErrValidation(map[string]string{
+			"format": "El formato debe ser json o csv",
+		})
+	}
+
+	// The hashes can be cracked offline, so they are only exported on
+	// request and to platform administrators.
+	includePasswordHashes := c.Query("include_password_hashes") == "true"
+	currentUser := c.MustGet("user").(models.User)
+	if includePasswordHashes && !handler.permissionsService.IsPlatformAdmin(currentUser.ID) {
+		return apperror.NewErrForbidden([]string{"platform_admin"}, []string{"platform_admin"})
+	}
+
+	rows := handler.usersImportService.Export(handler.organizationScope(c), includePasswordHashes)
+
+	if format == "json" {
+		return handler.JSONResponse(c, http.StatusOK, requests.ImportUsers{
+			Users: rows,
+		})
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(usersCSVHeader); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		attributes := ""
+		if len(row.Attributes) > 0 {
+			data, err := json.Marshal(row.Attributes)
+			if err != nil {
+				return err
+			}
+
+			attributes = string(data)
+		}
+
+		err := writer.Write([]string{
+			row.Username,
+			row.OrganizationName,
+			row.PasswordHash,
+			strconv.FormatBool(row.Invite),
+			row.Email,
+			row.DisplayName,
+			row.Locale,
+			strings.Join(row.Permissions, csvPermissionsSeparator),
+			attributes,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func NewUsersImportHandler(
+	logger logger.Logger,
+
+	usersImportService services.UsersImportService,
+	permissionsService services.PermissionsService,
+) *UsersImportHandler {
+	return &UsersImportHandler{
+		BaseHandler: BaseHandler{
+			logger: logger,
+		},
+
+		usersImportService: usersImportService,
+		permissionsService: permissionsService,
+	}
+}
@@ -1,10 +1,14 @@
 package wrappers
 
 import (
+	"fmt"
 	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
 	"go-crud-gin/internal/platform/authenticator"
 	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/routes"
 	"go-crud-gin/internal/services"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,26 +21,47 @@ type AuthenticatorWrapper struct {
 	logger        logger.Logger
 	authenticator authenticator.Authenticator
 	usersService  services.UsersService
+	routesTable   routes.Table
+
+	platformAdminPermission string
 }
 
-func (wrapper *AuthenticatorWrapper) Wrap(handler func(c *gin.Context) error, permissions []string) func(c *gin.Context) error {
+// Wrap authenticates the request with the requirements that the routes table
+// declares for the matched route.
+func (wrapper *AuthenticatorWrapper) Wrap(handler func(c *gin.Context) error) func(c *gin.Context) error {
 	return func(c *gin.Context) error {
+		route, ok := wrapper.routesTable.Get(c.Request.Method, c.FullPath())
+		if !ok {
+			return fmt.Errorf("route %s %s is not declared in the routes table", c.Request.Method, c.FullPath())
+		}
+
 		var headers authenticationHeaders
 
 		err := c.ShouldBindHeader(&headers)
 		if err == nil && headers.Authorization != nil {
-			jwt, err := wrapper.authenticator.Authenticate(*headers.Authorization, permissions)
+			jwt, err := wrapper.authenticator.Authenticate(*headers.Authorization, route.Permissions)
 			if err != nil {
 				return err
 			}
 
-			user := wrapper.usersService.GetByID(jwt.UserID)
+			user := wrapper.usersService.GetByPublicID(jwt.OrganizationID, jwt.UserID)
 			if user == nil {
-				return apperror.NewErrUnauthorized()
+				return apperror.NewErrInvalidToken()
+			}
+
+			// Tokens issued before a suspension stop working right away.
+			if user.Status == models.UserSuspended {
+				return apperror.NewErrUserSuspended(user.SuspensionReason, user.SuspendedUntil)
+			}
+
+			organizationID := user.OrganizationID
+			if slices.Contains(jwt.Permissions, wrapper.platformAdminPermission) {
+				organizationID = services.AllOrganizations
 			}
 
 			c.Set("user", *user)
-		} else if len(permissions) > 0 {
+			c.Set("organizationID", organizationID)
+		} else if route.AuthRequired {
 			return apperror.NewErrUnauthorized()
 		}
 
@@ -48,10 +73,15 @@ func NewAuthentiatorWrapper(
 	logger logger.Logger,
 	authenticator authenticator.Authenticator,
 	usersService services.UsersService,
+	routesTable routes.Table,
+	platformAdminPermission string,
 ) *AuthenticatorWrapper {
 	return &AuthenticatorWrapper{
 		logger:        logger,
 		authenticator: authenticator,
 		usersService:  usersService,
+		routesTable:   routesTable,
+
+		platformAdminPermission: platformAdminPermission,
 	}
 }
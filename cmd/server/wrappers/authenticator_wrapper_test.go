@@ -0,0 +1,147 @@
+package wrappers
+
+import (
+	"fmt"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/authenticator"
+	"go-crud-gin/internal/platform/routes"
+	"go-crud-gin/internal/repositories"
+	"go-crud-gin/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// benchmarkSizes are the numbers of users the benchmarks run with, each with
+// benchmarkGrantsPerUser grants, so the largest one holds 1M grants.
+var benchmarkSizes = []int{1_000, 10_000, 100_000}
+
+const (
+	benchmarkPermissions   = 100
+	benchmarkGrantsPerUser = 10
+
+	// benchmarkTokens bounds the tokens signed for each size, which would
+	// otherwise take longer than filling the store.
+	benchmarkTokens = 1_000
+)
+
+type benchmarkFixture struct {
+	engine *gin.Engine
+	tokens []string
+}
+
+// benchmarkFixtures caches the fixtures by number of users, since the
+// benchmarks run several times and filling the largest ones takes a while.
+var benchmarkFixtures = map[int]benchmarkFixture{}
+
+// newBenchmarkFixture serves the permissions of the current user behind the
+// concurrency and authenticator wrappers, like the app does.
+func newBenchmarkFixture(b *testing.B, users int) benchmarkFixture {
+	b.Helper()
+
+	if fixture, ok := benchmarkFixtures[users]; ok {
+		return fixture
+	}
+
+	store := repositories.NewMemoryStore()
+	usersService := services.NewUsersService(testLogger{}, services.DefaultUsersOptions(), store.Users())
+	groupsService := services.NewGroupsService(testLogger{}, store.Groups())
+	permissionsService := services.NewPermissionsService(testLogger{}, services.DefaultPermissionsOptions(), usersService, groupsService, store.Permissions(), store.Grants())
+
+	permissionIDs := make([]int, benchmarkPermissions)
+	for i := range permissionIDs {
+		permissionID, err := permissionsService.Create(services.GlobalOrganizationID, fmt.Sprintf("permission_%d", i), "")
+		if err != nil {
+			b.Fatalf("Create permission: %v", err)
+		}
+
+		permissionIDs[i] = permissionID
+	}
+
+	auth := authenticator.NewLocalAuthenticator(testLogger{})
+
+	tokens := []string{}
+	for i := 0; i < users; i++ {
+		userID, err := usersService.CreateWithPasswordHash(services.DefaultOrganizationID, fmt.Sprintf("user%d", i), "hash")
+		if err != nil {
+			b.Fatalf("Create user: %v", err)
+		}
+
+		for j := 0; j < benchmarkGrantsPerUser; j++ {
+			if err := permissionsService.AssignPermissionToUser(userID, permissionIDs[(i+j*7)%benchmarkPermissions]); err != nil {
+				b.Fatalf("AssignPermissionToUser: %v", err)
+			}
+		}
+
+		// The tokens spread over every user.
+		if i%(users/benchmarkTokens) == 0 {
+			user := usersService.GetByID(services.AllOrganizations, userID)
+
+			token, err := auth.GetToken(authenticator.AuthenticatorToken{
+				UserID:         user.PublicID,
+				OrganizationID: user.OrganizationID,
+				Permissions:    permissionsService.GetPermissionNamesForUser(userID),
+			})
+			if err != nil {
+				b.Fatalf("GetToken: %v", err)
+			}
+
+			tokens = append(tokens, "Bearer "+token)
+		}
+	}
+
+	table := routes.NewTable()
+	table.Replace([]routes.Route{
+		{Method: http.MethodGet, Path: "/me/permissions", AuthRequired: true},
+	})
+
+	concurrencyWrapper := NewConcurrencyWrapper(testLogger{}, DefaultConcurrencyOptions())
+	authenticatorWrapper := NewAuthentiatorWrapper(testLogger{}, auth, usersService, table, services.DefaultPermissionsOptions().PlatformAdminPermission)
+
+	handler := concurrencyWrapper.Wrap(authenticatorWrapper.Wrap(func(c *gin.Context) error {
+		user := c.MustGet("user").(models.User)
+		c.JSON(http.StatusOK, permissionsService.GetPermissionNamesForUser(user.ID))
+
+		return nil
+	}))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/me/permissions", func(c *gin.Context) {
+		if err := handler(c); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+
+	fixture := benchmarkFixture{
+		engine: engine,
+		tokens: tokens,
+	}
+
+	benchmarkFixtures[users] = fixture
+
+	return fixture
+}
+
+func BenchmarkAuthenticatorWrapper(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("users=%d", size), func(b *testing.B) {
+			fixture := newBenchmarkFixture(b, size)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				request := httptest.NewRequest(http.MethodGet, "/me/permissions", nil)
+				request.Header.Set("Authorization", fixture.tokens[i%len(fixture.tokens)])
+
+				recorder := httptest.NewRecorder()
+				fixture.engine.ServeHTTP(recorder, request)
+
+				if recorder.Code != http.StatusOK {
+					b.Fatalf("got status %d, want %d", recorder.Code, http.StatusOK)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package wrappers
+
+import (
+	"go-crud-gin/internal/platform/logger"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConcurrencyOptions struct {
+	// ReadOnlyRoutes are the routes besides the GET ones that do not change
+	// any data, as "METHOD /path", so they can run along with other reads.
+	ReadOnlyRoutes []string
+}
+
+func DefaultConcurrencyOptions() ConcurrencyOptions {
+	return ConcurrencyOptions{
+		ReadOnlyRoutes: []string{
+			"POST /auth/logIn",
+			"POST /authz/check",
+			"POST /authz/check/batch",
+		},
+	}
+}
+
+// ConcurrencyWrapper runs the requests that change data one at a time and
+// with no reads in between, so the checks a request makes before changing
+// something, like the uniqueness of usernames or the holders of critical
+// permissions, still hold when the change is applied. Reads run concurrently.
+type ConcurrencyWrapper struct {
+	logger  logger.Logger
+	options ConcurrencyOptions
+
+	mutex sync.RWMutex
+}
+
+func (wrapper *ConcurrencyWrapper) isReadOnly(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return slices.Contains(wrapper.options.ReadOnlyRoutes, c.Request.Method+" "+c.FullPath())
+}
+
+func (wrapper *ConcurrencyWrapper) Wrap(handler func(c *gin.Context) error) func(c *gin.Context) error {
+	return func(c *gin.Context) error {
+		if wrapper.isReadOnly(c) {
+			wrapper.mutex.RLock()
+			defer wrapper.mutex.RUnlock()
+		} else {
+			wrapper.mutex.Lock()
+			defer wrapper.mutex.Unlock()
+		}
+
+		return handler(c)
+	}
+}
+
+// Exclusive runs change like a request that changes data, for the changes
+// made outside requests.
+func (wrapper *ConcurrencyWrapper) Exclusive(change func()) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	change()
+}
+
+func NewConcurrencyWrapper(
+	logger logger.Logger,
+	options ConcurrencyOptions,
+) *ConcurrencyWrapper {
+	return &ConcurrencyWrapper{
+		logger:  logger,
+		options: options,
+	}
+}
@@ -0,0 +1,134 @@
+package wrappers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testLogger struct{}
+
+func (testLogger) Debugf(format string, args ...any) {}
+func (testLogger) Infof(format string, args ...any)  {}
+
+func newTestEngine(wrapper *ConcurrencyWrapper, handler func(c *gin.Context) error) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	wrapped := func(c *gin.Context) {
+		if err := wrapper.Wrap(handler)(c); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	}
+
+	engine := gin.New()
+	engine.GET("/users/", wrapped)
+	engine.POST("/users/", wrapped)
+	engine.DELETE("/users/:id", wrapped)
+	engine.POST("/authz/check", wrapped)
+
+	return engine
+}
+
+func serve(engine *gin.Engine, method, path string) {
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+func TestConcurrencyWrapperRunsReadsTogether(t *testing.T) {
+	wrapper := NewConcurrencyWrapper(testLogger{}, DefaultConcurrencyOptions())
+
+	// Every read waits for the other one, which only finishes in time if
+	// both hold the read lock at once.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	together := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(together)
+	}()
+
+	var timedOut atomic.Bool
+	engine := newTestEngine(wrapper, func(c *gin.Context) error {
+		arrived.Done()
+
+		select {
+		case <-together:
+		case <-time.After(2 * time.Second):
+			timedOut.Store(true)
+		}
+
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for _, request := range [][2]string{{"GET", "/users/"}, {"POST", "/authz/check"}} {
+		wg.Add(1)
+		go func(method, path string) {
+			defer wg.Done()
+			serve(engine, method, path)
+		}(request[0], request[1])
+	}
+	wg.Wait()
+
+	if timedOut.Load() {
+		t.Errorf("a GET and a read-only POST did not run together")
+	}
+}
+
+func TestConcurrencyWrapperRunsChangesAlone(t *testing.T) {
+	wrapper := NewConcurrencyWrapper(testLogger{}, DefaultConcurrencyOptions())
+
+	var readers, writers atomic.Int32
+	engine := newTestEngine(wrapper, func(c *gin.Context) error {
+		if c.Request.Method == http.MethodGet {
+			readers.Add(1)
+			defer readers.Add(-1)
+
+			if writers.Load() != 0 {
+				t.Errorf("a read ran along with a change")
+			}
+		} else {
+			defer writers.Add(-1)
+			if writers.Add(1) != 1 || readers.Load() != 0 {
+				t.Errorf("%s %s ran along with other requests", c.Request.Method, c.Request.URL.Path)
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	requests := [][2]string{{"GET", "/users/"}, {"POST", "/users/"}, {"DELETE", "/users/1"}}
+	for i := 0; i < 60; i++ {
+		request := requests[i%len(requests)]
+
+		wg.Add(1)
+		go func(method, path string) {
+			defer wg.Done()
+			serve(engine, method, path)
+		}(request[0], request[1])
+	}
+
+	var exclusive sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		exclusive.Add(1)
+		go func() {
+			defer exclusive.Done()
+			wrapper.Exclusive(func() {
+				defer writers.Add(-1)
+				if writers.Add(1) != 1 || readers.Load() != 0 {
+					t.Errorf("Exclusive ran along with other requests")
+				}
+			})
+		}()
+	}
+
+	wg.Wait()
+	exclusive.Wait()
+}
@@ -8,8 +8,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+type ErrorOptions struct {
+	// HideForbiddenDetails removes the required and missing permissions from
+	// forbidden errors, so they do not reveal the permission model.
+	HideForbiddenDetails bool
+}
+
+func DefaultErrorOptions() ErrorOptions {
+	return ErrorOptions{
+		HideForbiddenDetails: false,
+	}
+}
+
 type ErrorWrapper struct {
-	logger logger.Logger
+	logger  logger.Logger
+	options ErrorOptions
 }
 
 func (wrapper *ErrorWrapper) Wrap(handler func(c *gin.Context) error) gin.HandlerFunc {
@@ -24,14 +37,24 @@ func (wrapper *ErrorWrapper) Wrap(handler func(c *gin.Context) error) gin.Handle
 			appErr = apperror.NewErrInternalServerError(err)
 		}
 
+		if wrapper.options.HideForbiddenDetails && appErr.Code == apperror.ErrForbiddenCode {
+			appErr = apperror.NewErrForbidden(nil, nil)
+		}
+
+		for name, value := range appErr.Headers {
+			c.Header(name, value)
+		}
+
 		c.JSONP(appErr.StatusCode, appErr)
 	}
 }
 
 func NewErrorWrapper(
 	logger logger.Logger,
+	options ErrorOptions,
 ) *ErrorWrapper {
 	return &ErrorWrapper{
-		logger: logger,
+		logger:  logger,
+		options: options,
 	}
 }
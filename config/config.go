@@ -0,0 +1,14 @@
+package config
+
+import _ "embed"
+
+// Routes is the default routes config, used when the configured file does
+// not exist.
+//
+//go:embed routes.json
+var Routes []byte
+
+// Seed is the default seed, used when the configured file does not exist.
+//
+//go:embed seed.json
+var Seed []byte
@@ -3,8 +3,13 @@ package apperror
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// Realm is the protection space announced in WWW-Authenticate headers.
+const Realm = "go-crud-gin"
+
 const (
 	ErrInternalServerCode    = "internal_server_error"
 	ErrInternalServerMessage = "Error interno"
@@ -15,6 +20,9 @@ const (
 	ErrUnauthorizedCode    = "unauthorized_user"
 	ErrUnauthorizedMessage = "No tienes permitido consumir esta url"
 
+	ErrForbiddenCode    = "forbidden_user"
+	ErrForbiddenMessage = "No tienes los permisos necesarios para consumir esta url"
+
 	// Users
 	ErrUserWrongAuthenticationCode    = "wrong_authentication"
 	ErrUserWrongAuthenticationMessage = "El usuario o la contraseña no son correctos"
@@ -28,6 +36,25 @@ const (
 	ErrUserNotDeletableCode    = "cannot_delete_user"
 	ErrUserNotDeletableMessage = "No puedes eliminar el usuario con el que estás autenticado"
 
+	ErrUserNotSuspendableCode    = "cannot_suspend_user"
+	ErrUserNotSuspendableMessage = "No puedes suspender el usuario con el que estás autenticado"
+
+	ErrCannotManageUserCode    = "cannot_manage_user"
+	ErrCannotManageUserMessage = "No puedes cambiar la contraseña, el nombre de usuario ni los grupos de un usuario con permisos que no puedes otorgar"
+
+	ErrUserSuspendedCode    = "user_suspended"
+	ErrUserSuspendedMessage = "El usuario está suspendido"
+
+	ErrEmailAlreadyExistsCode    = "email_already_exists"
+	ErrEmailAlreadyExistsMessage = "El correo electrónico ya está en uso"
+
+	// User attributes
+	ErrUserAttributeAlreadyExistsCode    = "user_attribute_already_exists"
+	ErrUserAttributeAlreadyExistsMessage = "Ya existe un atributo con este nombre en otra organización"
+
+	ErrUserAttributeNotFoundCode    = "user_attribute_not_found"
+	ErrUserAttributeNotFoundMessage = "El atributo no existe"
+
 	// Permissions
 	ErrPermissionAlreadyExistsCode    = "permission_already_exists"
 	ErrPermissionAlreadyExistsMessage = "El nombre del permiso ya está en uso"
@@ -38,6 +65,18 @@ const (
 	ErrPermissionNotDeletableCode    = "permission_not_deletable"
 	ErrPermissionNotDeletableMessage = "No puedes eliminar este permiso"
 
+	ErrPermissionNotEditableCode    = "permission_not_editable"
+	ErrPermissionNotEditableMessage = "No puedes modificar este permiso"
+
+	ErrPermissionNotRenamableCode    = "permission_not_renamable"
+	ErrPermissionNotRenamableMessage = "No puedes cambiarle el nombre a este permiso"
+
+	ErrPermissionRequiredByRoutesCode    = "permission_required_by_routes"
+	ErrPermissionRequiredByRoutesMessage = "El permiso es requerido por la configuración de rutas; quítalo de ella y recárgala antes de eliminarlo"
+
+	ErrLastPermissionHolderCode    = "last_permission_holder"
+	ErrLastPermissionHolderMessage = "La operación dejaría sin usuarios al permiso %s"
+
 	// User permissions
 	ErrUserAlreadyHasPermissionCode    = "user_has_permission"
 	ErrUserAlreadyHasPermissionMessage = "El usuario ya posee este permiso"
@@ -47,6 +86,68 @@ const (
 
 	ErrCannotRevokeUserPermissionCode    = "cannot_revoke_permission"
 	ErrCannotRevokeUserPermissionMessage = "No puedes eliminarle un permiso al usuario con el que estás autenticado"
+
+	ErrCannotGrantPermissionCode    = "cannot_grant_permission"
+	ErrCannotGrantPermissionMessage = "No puedes otorgar un permiso que no posees"
+
+	ErrPermissionSuperuserOnlyCode    = "permission_superuser_only"
+	ErrPermissionSuperuserOnlyMessage = "Sólo un superusuario puede otorgar este permiso"
+
+	ErrPermissionPlatformOnlyCode    = "permission_platform_only"
+	ErrPermissionPlatformOnlyMessage = "Sólo un administrador de la plataforma puede otorgar este permiso"
+
+	// Organizations
+	ErrOrganizationAlreadyExistsCode    = "organization_already_exists"
+	ErrOrganizationAlreadyExistsMessage = "El nombre de la organización ya está en uso"
+
+	ErrOrganizationNotFoundCode    = "organization_not_found"
+	ErrOrganizationNotFoundMessage = "La organización no existe"
+
+	// Groups
+	ErrGroupAlreadyExistsCode    = "group_already_exists"
+	ErrGroupAlreadyExistsMessage = "El nombre del grupo ya está en uso"
+
+	ErrGroupNotFoundCode    = "group_not_found"
+	ErrGroupNotFoundMessage = "El grupo no existe"
+
+	ErrGroupHasSubgroupsCode    = "group_has_subgroups"
+	ErrGroupHasSubgroupsMessage = "No puedes eliminar un grupo que tiene subgrupos"
+
+	ErrUserAlreadyInGroupCode    = "user_already_in_group"
+	ErrUserAlreadyInGroupMessage = "El usuario ya pertenece al grupo"
+
+	ErrUserNotInGroupCode    = "user_not_in_group"
+	ErrUserNotInGroupMessage = "El usuario no pertenece al grupo"
+
+	ErrCannotManageGroupCode    = "cannot_manage_group"
+	ErrCannotManageGroupMessage = "No puedes añadir miembros ni subgrupos a un grupo con permisos que no puedes otorgar"
+
+	// Group permissions
+	ErrGroupAlreadyHasPermissionCode    = "group_has_permission"
+	ErrGroupAlreadyHasPermissionMessage = "El grupo ya posee este permiso"
+
+	ErrGroupPermissionNotFoundCode    = "group_not_has_permission"
+	ErrGroupPermissionNotFoundMessage = "El grupo no posee este permiso"
+
+	// Access requests
+	ErrAccessRequestNotFoundCode    = "access_request_not_found"
+	ErrAccessRequestNotFoundMessage = "La solicitud de acceso no existe"
+
+	ErrAccessRequestAlreadyExistsCode    = "access_request_already_exists"
+	ErrAccessRequestAlreadyExistsMessage = "Ya tienes una solicitud de acceso pendiente para este permiso"
+
+	ErrAccessRequestNotPendingCode    = "access_request_not_pending"
+	ErrAccessRequestNotPendingMessage = "La solicitud de acceso ya fue resuelta o expiró"
+
+	ErrAccessRequestAlreadyApprovedCode    = "access_request_already_approved"
+	ErrAccessRequestAlreadyApprovedMessage = "Ya aprobaste esta solicitud de acceso"
+
+	ErrCannotReviewAccessRequestCode    = "cannot_review_access_request"
+	ErrCannotReviewAccessRequestMessage = "No puedes revisar tus propias solicitudes de acceso"
+
+	// Routes
+	ErrInvalidRoutesConfigCode    = "invalid_routes_config"
+	ErrInvalidRoutesConfigMessage = "La configuración de rutas no es válida"
 )
 
 type AppError struct {
@@ -55,6 +156,12 @@ type AppError struct {
 	Message           string            `json:"message"`
 	Details           *error            `json:"details,omitempty"`
 	ValidationDetails map[string]string `json:"validation_details,omitempty"`
+
+	RequiredPermissions []string `json:"required_permissions,omitempty"`
+	MissingPermissions  []string `json:"missing_permissions,omitempty"`
+
+	// Headers are sent along with the error response.
+	Headers map[string]string `json:"-"`
 }
 
 func (appError *AppError) Error() string {
@@ -83,11 +190,48 @@ func NewErrValidation(validationDetails map[string]string) *AppError {
 	}
 }
 
+// NewErrUnauthorized is returned when the request has no bearer token.
 func NewErrUnauthorized() *AppError {
 	return &AppError{
 		StatusCode: http.StatusUnauthorized,
 		Code:       ErrUnauthorizedCode,
 		Message:    ErrUnauthorizedMessage,
+		Headers: map[string]string{
+			"WWW-Authenticate": fmt.Sprintf(`Bearer realm="%s"`, Realm),
+		},
+	}
+}
+
+// NewErrInvalidToken is returned when the bearer token is malformed, expired
+// or belongs to a user that no longer exists.
+func NewErrInvalidToken() *AppError {
+	return &AppError{
+		StatusCode: http.StatusUnauthorized,
+		Code:       ErrUnauthorizedCode,
+		Message:    ErrUnauthorizedMessage,
+		Headers: map[string]string{
+			"WWW-Authenticate": fmt.Sprintf(`Bearer realm="%s", error="invalid_token", error_description="The access token is invalid or expired"`, Realm),
+		},
+	}
+}
+
+// NewErrForbidden is returned when a valid token lacks the required
+// permissions. Without permissions it reveals nothing about them.
+func NewErrForbidden(requiredPermissions, missingPermissions []string) *AppError {
+	challenge := fmt.Sprintf(`Bearer realm="%s", error="insufficient_scope"`, Realm)
+	if len(requiredPermissions) > 0 {
+		challenge += fmt.Sprintf(`, scope="%s"`, strings.Join(requiredPermissions, " "))
+	}
+
+	return &AppError{
+		StatusCode:          http.StatusForbidden,
+		Code:                ErrForbiddenCode,
+		Message:             ErrForbiddenMessage,
+		RequiredPermissions: requiredPermissions,
+		MissingPermissions:  missingPermissions,
+		Headers: map[string]string{
+			"WWW-Authenticate": challenge,
+		},
 	}
 }
 
@@ -124,6 +268,70 @@ func NewErrUserNotDeletable() *AppError {
 	}
 }
 
+func NewErrUserNotSuspendable() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrUserNotSuspendableCode,
+		Message:    ErrUserNotSuspendableMessage,
+	}
+}
+
+// NewErrCannotManageUser is returned when changing the credentials or the
+// groups of a user with permissions the caller could not grant, which would
+// let the caller act as that user.
+func NewErrCannotManageUser() *AppError {
+	return &AppError{
+		StatusCode: http.StatusForbidden,
+		Code:       ErrCannotManageUserCode,
+		Message:    ErrCannotManageUserMessage,
+	}
+}
+
+// NewErrUserSuspended is returned when a suspended user logs in or uses a
+// token issued before the suspension. The message includes the optional
+// reason and end of the suspension.
+func NewErrUserSuspended(reason string, until *time.Time) *AppError {
+	message := ErrUserSuspendedMessage
+	if until != nil {
+		message += " hasta " + until.UTC().Format(time.RFC3339)
+	}
+
+	if reason != "" {
+		message += ": " + reason
+	}
+
+	return &AppError{
+		StatusCode: http.StatusForbidden,
+		Code:       ErrUserSuspendedCode,
+		Message:    message,
+	}
+}
+
+func NewErrEmailAlreadyExists() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrEmailAlreadyExistsCode,
+		Message:    ErrEmailAlreadyExistsMessage,
+	}
+}
+
+// User attributes
+func NewErrUserAttributeAlreadyExists() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrUserAttributeAlreadyExistsCode,
+		Message:    ErrUserAttributeAlreadyExistsMessage,
+	}
+}
+
+func NewErrUserAttributeNotFound() *AppError {
+	return &AppError{
+		StatusCode: http.StatusNotFound,
+		Code:       ErrUserAttributeNotFoundCode,
+		Message:    ErrUserAttributeNotFoundMessage,
+	}
+}
+
 // Permissions
 func NewErrPermissionAlreadyExists() *AppError {
 	return &AppError{
@@ -149,6 +357,42 @@ func NewErrPermissionNotDeletable() *AppError {
 	}
 }
 
+func NewErrPermissionNotEditable() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrPermissionNotEditableCode,
+		Message:    ErrPermissionNotEditableMessage,
+	}
+}
+
+func NewErrPermissionNotRenamable() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrPermissionNotRenamableCode,
+		Message:    ErrPermissionNotRenamableMessage,
+	}
+}
+
+// NewErrPermissionRequiredByRoutes is returned when deleting a
+// permission would leave the routes config requiring a missing one.
+func NewErrPermissionRequiredByRoutes() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrPermissionRequiredByRoutesCode,
+		Message:    ErrPermissionRequiredByRoutesMessage,
+	}
+}
+
+// NewErrLastPermissionHolder is returned when a change would leave a critical
+// permission without holders.
+func NewErrLastPermissionHolder(permissionName string) *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrLastPermissionHolderCode,
+		Message:    fmt.Sprintf(ErrLastPermissionHolderMessage, permissionName),
+	}
+}
+
 // User permissions
 func NewErrUserAlreadyHasPermission() *AppError {
 	return &AppError{
@@ -173,3 +417,164 @@ func NewErrCannotRevokeUserPermission() *AppError {
 		Message:    ErrCannotRevokeUserPermissionMessage,
 	}
 }
+
+func NewErrCannotGrantPermission() *AppError {
+	return &AppError{
+		StatusCode: http.StatusForbidden,
+		Code:       ErrCannotGrantPermissionCode,
+		Message:    ErrCannotGrantPermissionMessage,
+	}
+}
+
+func NewErrPermissionSuperuserOnly() *AppError {
+	return &AppError{
+		StatusCode: http.StatusForbidden,
+		Code:       ErrPermissionSuperuserOnlyCode,
+		Message:    ErrPermissionSuperuserOnlyMessage,
+	}
+}
+
+func NewErrPermissionPlatformOnly() *AppError {
+	return &AppError{
+		StatusCode: http.StatusForbidden,
+		Code:       ErrPermissionPlatformOnlyCode,
+		Message:    ErrPermissionPlatformOnlyMessage,
+	}
+}
+
+// Organizations
+func NewErrOrganizationAlreadyExists() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrOrganizationAlreadyExistsCode,
+		Message:    ErrOrganizationAlreadyExistsMessage,
+	}
+}
+
+func NewErrOrganizationNotFound() *AppError {
+	return &AppError{
+		StatusCode: http.StatusNotFound,
+		Code:       ErrOrganizationNotFoundCode,
+		Message:    ErrOrganizationNotFoundMessage,
+	}
+}
+
+// Groups
+func NewErrGroupAlreadyExists() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrGroupAlreadyExistsCode,
+		Message:    ErrGroupAlreadyExistsMessage,
+	}
+}
+
+func NewErrGroupNotFound() *AppError {
+	return &AppError{
+		StatusCode: http.StatusNotFound,
+		Code:       ErrGroupNotFoundCode,
+		Message:    ErrGroupNotFoundMessage,
+	}
+}
+
+func NewErrGroupHasSubgroups() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrGroupHasSubgroupsCode,
+		Message:    ErrGroupHasSubgroupsMessage,
+	}
+}
+
+func NewErrUserAlreadyInGroup() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrUserAlreadyInGroupCode,
+		Message:    ErrUserAlreadyInGroupMessage,
+	}
+}
+
+func NewErrUserNotInGroup() *AppError {
+	return &AppError{
+		StatusCode: http.StatusBadRequest,
+		Code:       ErrUserNotInGroupCode,
+		Message:    ErrUserNotInGroupMessage,
+	}
+}
+
+// NewErrCannotManageGroup is returned when adding members or subgroups to a
+// group with permissions the caller could not grant, since they would
+// inherit them.
+func NewErrCannotManageGroup() *AppError {
+	return &AppError{
+		StatusCode: http.StatusForbidden,
+		Code:       ErrCannotManageGroupCode,
+		Message:    ErrCannotManageGroupMessage,
+	}
+}
+
+// Group permissions
+func NewErrGroupAlreadyHasPermission() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrGroupAlreadyHasPermissionCode,
+		Message:    ErrGroupAlreadyHasPermissionMessage,
+	}
+}
+
+func NewErrGroupPermissionNotFound() *AppError {
+	return &AppError{
+		StatusCode: http.StatusBadRequest,
+		Code:       ErrGroupPermissionNotFoundCode,
+		Message:    ErrGroupPermissionNotFoundMessage,
+	}
+}
+
+// Access requests
+func NewErrAccessRequestNotFound() *AppError {
+	return &AppError{
+		StatusCode: http.StatusNotFound,
+		Code:       ErrAccessRequestNotFoundCode,
+		Message:    ErrAccessRequestNotFoundMessage,
+	}
+}
+
+func NewErrAccessRequestAlreadyExists() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrAccessRequestAlreadyExistsCode,
+		Message:    ErrAccessRequestAlreadyExistsMessage,
+	}
+}
+
+func NewErrAccessRequestNotPending() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrAccessRequestNotPendingCode,
+		Message:    ErrAccessRequestNotPendingMessage,
+	}
+}
+
+func NewErrAccessRequestAlreadyApproved() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrAccessRequestAlreadyApprovedCode,
+		Message:    ErrAccessRequestAlreadyApprovedMessage,
+	}
+}
+
+func NewErrCannotReviewAccessRequest() *AppError {
+	return &AppError{
+		StatusCode: http.StatusConflict,
+		Code:       ErrCannotReviewAccessRequestCode,
+		Message:    ErrCannotReviewAccessRequestMessage,
+	}
+}
+
+// Routes
+func NewErrInvalidRoutesConfig(validationDetails map[string]string) *AppError {
+	return &AppError{
+		StatusCode:        http.StatusBadRequest,
+		Code:              ErrInvalidRoutesConfigCode,
+		Message:           ErrInvalidRoutesConfigMessage,
+		ValidationDetails: validationDetails,
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+	AccessRequestExpired  AccessRequestStatus = "expired"
+)
+
+type AccessRequest struct {
+	ID                int                 `json:"id"`
+	OrganizationID    int                 `json:"organization_id"`
+	UserID            int                 `json:"user_id"`
+	PermissionID      int                 `json:"permission_id"`
+	Justification     string              `json:"justification"`
+	Status            AccessRequestStatus `json:"status"`
+	RequiredApprovals int                 `json:"required_approvals"`
+	ApprovedBy        []int               `json:"approved_by"`
+	DeniedBy          *int                `json:"denied_by,omitempty"`
+	CreatedAt         time.Time           `json:"created_at"`
+	ExpiresAt         time.Time           `json:"expires_at"`
+	ResolvedAt        *time.Time          `json:"resolved_at,omitempty"`
+
+	// ResolutionReason explains the requests resolved without the usual
+	// grant, e.g. because the user got the permission meanwhile.
+	ResolutionReason string `json:"resolution_reason,omitempty"`
+}
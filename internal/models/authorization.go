@@ -0,0 +1,35 @@
+package models
+
+type AuthorizationReasonCode string
+
+const (
+	AuthorizationReasonNoPermissionsRequired AuthorizationReasonCode = "no_permissions_required"
+	AuthorizationReasonDirectGrant           AuthorizationReasonCode = "direct_grant"
+	AuthorizationReasonGroupGrant            AuthorizationReasonCode = "group_grant"
+	AuthorizationReasonMissingPermissions    AuthorizationReasonCode = "missing_permissions"
+	AuthorizationReasonSubjectNotFound       AuthorizationReasonCode = "subject_not_found"
+	AuthorizationReasonSubjectSuspended      AuthorizationReasonCode = "subject_suspended"
+	AuthorizationReasonOrganizationMismatch  AuthorizationReasonCode = "organization_mismatch"
+	AuthorizationReasonActionNotFound        AuthorizationReasonCode = "action_not_found"
+	AuthorizationReasonAttributesMatch       AuthorizationReasonCode = "attributes_match"
+	AuthorizationReasonAttributesMismatch    AuthorizationReasonCode = "attributes_mismatch"
+)
+
+// AuthorizationResource is the optional resource an authorization check is
+// about. Only its organization takes part in the decision.
+type AuthorizationResource struct {
+	Type           string `json:"type"`
+	ID             string `json:"id"`
+	OrganizationID *int   `json:"organization_id"`
+}
+
+type AuthorizationReason struct {
+	Code    AuthorizationReasonCode `json:"code"`
+	Message string                  `json:"message"`
+}
+
+type AuthorizationDecision struct {
+	Allowed            bool                  `json:"allowed"`
+	MatchedPermissions []string              `json:"matched_permissions"`
+	Reasons            []AuthorizationReason `json:"reasons"`
+}
@@ -0,0 +1,14 @@
+package models
+
+type Group struct {
+	ID             int    `json:"id"`
+	OrganizationID int    `json:"organization_id"`
+	Name           string `json:"group_name"`
+	Description    string `json:"description"`
+	ParentID       *int   `json:"parent_id,omitempty"`
+}
+
+type GroupMember struct {
+	GroupID int `json:"group_id"`
+	UserID  int `json:"user_id"`
+}
@@ -0,0 +1,6 @@
+package models
+
+type Organization struct {
+	ID   int    `json:"id"`
+	Name string `json:"organization_name"`
+}
@@ -1,13 +1,55 @@
 package models
 
+import "time"
+
+// Permission is identified by ID internally and by PublicID in the API.
 type Permission struct {
-	ID          int    `json:"id"`
-	Name        string `json:"permission_name"`
-	Description string `json:"description"`
-	Deletable   bool   `json:"-"`
+	ID             int       `json:"-"`
+	PublicID       string    `json:"id"`
+	OrganizationID int       `json:"organization_id"`
+	Name           string    `json:"permission_name"`
+	Description    string    `json:"description"`
+	Deletable      bool      `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type UserPermission struct {
 	UserID       int `json:"user_id"`
 	PermissionID int `json:"permission_id"`
 }
+
+type GroupPermission struct {
+	GroupID      int `json:"group_id"`
+	PermissionID int `json:"permission_id"`
+}
+
+// EffectivePermission is a permission held by an user, either granted
+// directly (GroupID is nil) or inherited from one of their groups.
+type EffectivePermission struct {
+	PermissionID int  `json:"permission_id"`
+	GroupID      *int `json:"group_id,omitempty"`
+}
+
+type PermissionAction string
+
+const (
+	PermissionActionGrant  PermissionAction = "grant"
+	PermissionActionRevoke PermissionAction = "revoke"
+)
+
+// UserPermissionOperation grants or revokes a permission to an user as part
+// of a bulk change.
+type UserPermissionOperation struct {
+	OrganizationID int
+	UserID         int
+	PermissionName string
+	Action         PermissionAction
+}
+
+// PermissionHolder is a user that holds a permission directly, through the
+// groups in GroupIDs, or both.
+type PermissionHolder struct {
+	UserID   int   `json:"user_id"`
+	Direct   bool  `json:"direct"`
+	GroupIDs []int `json:"group_ids"`
+}
@@ -0,0 +1,27 @@
+package models
+
+type SeedPermission struct {
+	Name        string `json:"permission_name"`
+	Description string `json:"description"`
+	BuiltIn     bool   `json:"built_in"`
+}
+
+// SeedUser needs either a bcrypt PasswordHash or GeneratePassword, which
+// creates the user with a random password that is printed once.
+type SeedUser struct {
+	Username         string `json:"username"`
+	OrganizationName string `json:"organization_name"`
+	PasswordHash     string `json:"password_hash"`
+	GeneratePassword bool   `json:"generate_password"`
+}
+
+type SeedGrant struct {
+	Username       string `json:"username"`
+	PermissionName string `json:"permission_name"`
+}
+
+type Seed struct {
+	Permissions []SeedPermission `json:"permissions"`
+	Users       []SeedUser       `json:"users"`
+	Grants      []SeedGrant      `json:"grants"`
+}
@@ -1,7 +1,41 @@
 package models
 
+import "time"
+
+type UserStatus string
+
+const (
+	UserActive    UserStatus = "active"
+	UserSuspended UserStatus = "suspended"
+)
+
+// User is identified by ID internally and by PublicID in the API, so
+// responses do not reveal how many users exist.
 type User struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"-"`
+	ID               int            `json:"-"`
+	PublicID         string         `json:"id"`
+	OrganizationID   int            `json:"organization_id"`
+	Username         string         `json:"username"`
+	DisplayName      string         `json:"display_name"`
+	Email            string         `json:"email"`
+	Locale           string         `json:"locale"`
+	Attributes       map[string]any `json:"attributes"`
+	Status           UserStatus     `json:"status"`
+	SuspensionReason string         `json:"suspension_reason,omitempty"`
+	SuspendedUntil   *time.Time     `json:"suspended_until,omitempty"`
+	PasswordHash     string         `json:"-"`
+	CreatedAt        time.Time      `json:"created_at"`
+	DeletedAt        *time.Time     `json:"deleted_at,omitempty"`
+}
+
+// UserUpdate holds the fields to change on an user; nil fields are kept.
+type UserUpdate struct {
+	Username    *string
+	Password    *string
+	DisplayName *string
+	Email       *string
+	Locale      *string
+
+	// Attributes replaces every custom attribute of the user when it is not nil.
+	Attributes map[string]any
 }
@@ -0,0 +1,20 @@
+package models
+
+type UserAttributeType string
+
+const (
+	UserAttributeString  UserAttributeType = "string"
+	UserAttributeNumber  UserAttributeType = "number"
+	UserAttributeBoolean UserAttributeType = "boolean"
+)
+
+// UserAttributeDefinition describes a custom attribute of the users of
+// OrganizationID, or of every organization when it is GlobalOrganizationID.
+// AllowedValues is empty when any value of Type is allowed.
+type UserAttributeDefinition struct {
+	OrganizationID int               `json:"organization_id"`
+	Name           string            `json:"name"`
+	Type           UserAttributeType `json:"type"`
+	Required       bool              `json:"required"`
+	AllowedValues  []any             `json:"allowed_values"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// UserImportRow is an user as it is imported and exported, so an export can
+// be imported again. Invited users get a temporary password instead of
+// PasswordHash.
+type UserImportRow struct {
+	Username         string   `json:"username"`
+	OrganizationName string   `json:"organization_name,omitempty"`
+	PasswordHash     string   `json:"password_hash,omitempty"`
+	Invite           bool     `json:"invite,omitempty"`
+	Email            string   `json:"email,omitempty"`
+	DisplayName      string   `json:"display_name,omitempty"`
+	Locale           string   `json:"locale,omitempty"`
+	Permissions      []string `json:"permissions"`
+
+	// Attributes are the custom attributes of the user, validated like when
+	// they are updated.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
@@ -1,11 +1,31 @@
 package authenticator
 
+import "slices"
+
 type AuthenticatorToken struct {
-	UserID      int
-	Permissions []string
+	// UserID is the public ID of the user, since tokens can be decoded by anyone.
+	UserID         string
+	OrganizationID int
+	Permissions    []string
 }
 
 type Authenticator interface {
 	GetToken(data AuthenticatorToken) (string, error)
 	Authenticate(token string, permissions []string) (*AuthenticatorToken, error)
 }
+
+// HasAnyPermission reports whether held contains any of the required
+// permissions. An empty required list is always satisfied.
+func HasAnyPermission(held, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+
+	for _, permission := range required {
+		if slices.Contains(held, permission) {
+			return true
+		}
+	}
+
+	return false
+}
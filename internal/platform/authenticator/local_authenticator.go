@@ -2,7 +2,6 @@ package authenticator
 
 import (
 	"slices"
-	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +15,8 @@ const jwtSecret = "Abecedario"
 
 type tokenClaims struct {
 	jwt.StandardClaims
-	Permissions []string `json:"permissions,omitempty"`
+	OrganizationID int      `json:"organization_id"`
+	Permissions    []string `json:"permissions,omitempty"`
 }
 
 func (c tokenClaims) Validate() error {
@@ -30,10 +30,11 @@ type localAuthenticator struct {
 func (auth *localAuthenticator) GetToken(data AuthenticatorToken) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
 		StandardClaims: jwt.StandardClaims{
-			Subject:   strconv.Itoa(data.UserID),
+			Subject:   data.UserID,
 			ExpiresAt: time.Now().Add(time.Duration(1) * time.Hour).Unix(),
 		},
-		Permissions: data.Permissions,
+		OrganizationID: data.OrganizationID,
+		Permissions:    data.Permissions,
 	})
 
 	return token.SignedString([]byte(jwtSecret))
@@ -51,29 +52,24 @@ func (auth *localAuthenticator) Authenticate(tokenStr string, permissions []stri
 	})
 
 	if err != nil || !token.Valid {
-		return nil, apperror.NewErrUnauthorized()
-	}
-
-	userID, err := strconv.Atoi(claims.Subject)
-	if err != nil {
-		return nil, apperror.NewErrUnauthorized()
+		return nil, apperror.NewErrInvalidToken()
 	}
 
-	canConsume := false
-	for _, permission := range permissions {
-		if slices.Contains(claims.Permissions, permission) {
-			canConsume = true
-			break
+	if !HasAnyPermission(claims.Permissions, permissions) {
+		missing := []string{}
+		for _, permission := range permissions {
+			if !slices.Contains(claims.Permissions, permission) {
+				missing = append(missing, permission)
+			}
 		}
-	}
 
-	if !canConsume {
-		return nil, apperror.NewErrUnauthorized()
+		return nil, apperror.NewErrForbidden(permissions, missing)
 	}
 
 	return &AuthenticatorToken{
-		UserID:      userID,
-		Permissions: claims.Permissions,
+		UserID:         claims.Subject,
+		OrganizationID: claims.OrganizationID,
+		Permissions:    claims.Permissions,
 	}, nil
 }
 
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Open connects to the database at dsn with the driver of dialect, "sqlite"
+// (github.com/mattn/go-sqlite3, which needs cgo) or "postgres"
+// (github.com/lib/pq).
+func Open(dialect, dsn string) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+
+	switch dialect {
+	case "sqlite":
+		// SQLite only enforces the foreign keys when asked to.
+		if !strings.Contains(dsn, "_foreign_keys") && !strings.Contains(dsn, "_fk") {
+			separator := "?"
+			if strings.Contains(dsn, "?") {
+				separator = "&"
+			}
+
+			dsn += separator + "_foreign_keys=on"
+		}
+
+		db, err = sql.Open("sqlite3", dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		// SQLite allows a single writer, so sharing one connection avoids
+		// "database is locked" errors.
+		db.SetMaxOpenConns(1)
+	case "postgres":
+		db, err = sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown database dialect %q", dialect)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,162 @@
+package pagination
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query asks for a page of at most Limit items sorted by Sort, starting
+// after the item the opaque Cursor points to.
+type Query struct {
+	Limit      int
+	Cursor     string
+	Sort       string
+	Descending bool
+}
+
+type Page[T any] struct {
+	Items      []T
+	Total      int
+	NextCursor string
+}
+
+// Field is a sortable field. Values are compared as strings so they can be
+// stored in cursors.
+type Field[T any] struct {
+	Value   func(item T) string
+	Compare func(a, b string) int
+}
+
+func CompareInts(a, b string) int {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+
+	return cmp.Compare(x, y)
+}
+
+func CompareStrings(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func CompareTimes(a, b string) int {
+	x, _ := time.Parse(time.RFC3339Nano, a)
+	y, _ := time.Parse(time.RFC3339Nano, b)
+
+	return x.Compare(y)
+}
+
+func FormatTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+type cursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d"`
+	Value      string `json:"v"`
+	ID         string `json:"i"`
+}
+
+func encodeCursor(value cursor) string {
+	data, _ := json.Marshal(value)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, false
+	}
+
+	var decoded cursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, false
+	}
+
+	return &decoded, true
+}
+
+// Paginate sorts items by the query field and then by ID, so every item has a
+// stable position that cursors can point to even if items change between
+// requests. IDs end up in cursors, so they should be the public ones.
+func Paginate[T any](items []T, query Query, fields map[string]Field[T], id func(item T) string) (*Page[T], error) {
+	field, ok := fields[query.Sort]
+	if !ok {
+		names := []string{}
+		for name := range fields {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		return nil, apperror.NewErrValidation(map[string]string{
+			"sort": fmt.Sprintf("Sólo se puede ordenar por %s", strings.Join(names, ", ")),
+		})
+	}
+
+	compare := func(value string, itemID string, item T) int {
+		result := field.Compare(value, field.Value(item))
+		if result == 0 {
+			result = strings.Compare(itemID, id(item))
+		}
+
+		if query.Descending {
+			return -result
+		}
+
+		return result
+	}
+
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b T) int {
+		return compare(field.Value(a), id(a), b)
+	})
+
+	start := 0
+	if query.Cursor != "" {
+		after, ok := decodeCursor(query.Cursor)
+		if !ok || after.Sort != query.Sort || after.Descending != query.Descending {
+			return nil, apperror.NewErrValidation(map[string]string{
+				"cursor": "El cursor no es válido para este ordenamiento",
+			})
+		}
+
+		start = len(sorted)
+		for i, item := range sorted {
+			if compare(after.Value, after.ID, item) < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := min(start+query.Limit, len(sorted))
+
+	page := &Page[T]{
+		Items: sorted[start:end],
+		Total: len(sorted),
+	}
+
+	if end < len(sorted) {
+		last := sorted[end-1]
+		page.NextCursor = encodeCursor(cursor{
+			Sort:       query.Sort,
+			Descending: query.Descending,
+			Value:      field.Value(last),
+			ID:         id(last),
+		})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-crud-gin/config"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/platform/logger"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type Options struct {
+	// ConfigPath is the routes config file. When it does not exist the copy
+	// embedded in the binary is used.
+	ConfigPath string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		ConfigPath: "config/routes.json",
+	}
+}
+
+var methods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// Loader fills the table with the routes config, after checking that it
+// declares exactly the registered routes and only existing permissions.
+type Loader interface {
+	Register(method, path string)
+	Load() error
+
+	// RenamePermission rewrites the permission in the config file as well as
+	// in the table, so the routes keep requiring it after the next load.
+	RenamePermission(oldName, newName string) (int, error)
+}
+
+type loader struct {
+	logger  logger.Logger
+	options Options
+	table   Table
+
+	permissionExists func(name string) bool
+
+	mutex      sync.Mutex
+	registered []Route
+}
+
+func (loader *loader) Register(method, path string) {
+	loader.mutex.Lock()
+	defer loader.mutex.Unlock()
+
+	loader.registered = append(loader.registered, Route{
+		Method: method,
+		Path:   path,
+	})
+}
+
+func (loader *loader) read() ([]byte, error) {
+	data, err := os.ReadFile(loader.options.ConfigPath)
+	if errors.Is(err, os.ErrNotExist) {
+		loader.logger.Infof("[RoutesLoader] %s not found, using the embedded routes config", loader.options.ConfigPath)
+		return config.Routes, nil
+	}
+
+	return data, err
+}
+
+func (loader *loader) validate(routes []Route) map[string]string {
+	validationErrors := map[string]string{}
+
+	seen := map[string]bool{}
+	for i, route := range routes {
+		field := fmt.Sprintf("routes[%d]", i)
+		key := route.Method + " " + route.Path
+
+		if !slices.Contains(methods, route.Method) {
+			validationErrors[field+".method"] = fmt.Sprintf("El método %s no es válido", route.Method)
+		}
+
+		if seen[key] {
+			validationErrors[field+".path"] = fmt.Sprintf("La ruta %s está declarada más de una vez", key)
+		} else if !slices.ContainsFunc(loader.registered, func(registered Route) bool {
+			return registered.Method == route.Method && registered.Path == route.Path
+		}) {
+			validationErrors[field+".path"] = fmt.Sprintf("La ruta %s no existe", key)
+		}
+
+		seen[key] = true
+
+		if len(route.Permissions) > 0 && !route.AuthRequired {
+			validationErrors[field+".auth_required"] = "Las rutas que requieren permisos también requieren autenticación"
+		}
+
+		missing := []string{}
+		for _, permission := range route.Permissions {
+			if !loader.permissionExists(permission) {
+				missing = append(missing, permission)
+			}
+		}
+
+		if len(missing) > 0 {
+			validationErrors[field+".permissions"] = fmt.Sprintf("Los permisos %s no existen", strings.Join(missing, ", "))
+		}
+	}
+
+	for _, registered := range loader.registered {
+		key := registered.Method + " " + registered.Path
+		if !seen[key] {
+			validationErrors[key] = "La ruta no está declarada en la configuración"
+		}
+	}
+
+	return validationErrors
+}
+
+// Load replaces the routes of the table only when the whole config is valid.
+func (loader *loader) Load() error {
+	loader.mutex.Lock()
+	defer loader.mutex.Unlock()
+
+	data, err := loader.read()
+	if err != nil {
+		return err
+	}
+
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return apperror.NewErrInvalidRoutesConfig(map[string]string{
+			"config": err.Error(),
+		})
+	}
+
+	for i := range routes {
+		routes[i].Method = strings.ToUpper(routes[i].Method)
+		if routes[i].Permissions == nil {
+			routes[i].Permissions = []string{}
+		}
+	}
+
+	validationErrors := loader.validate(routes)
+	if len(validationErrors) > 0 {
+		return apperror.NewErrInvalidRoutesConfig(validationErrors)
+	}
+
+	loader.table.Replace(routes)
+
+	loader.logger.Infof("[RoutesLoader] %d routes loaded!", len(routes))
+
+	return nil
+}
+
+func (loader *loader) RenamePermission(oldName, newName string) (int, error) {
+	loader.mutex.Lock()
+	defer loader.mutex.Unlock()
+
+	data, err := loader.read()
+	if err != nil {
+		return 0, err
+	}
+
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return 0, apperror.NewErrInvalidRoutesConfig(map[string]string{
+			"config": err.Error(),
+		})
+	}
+
+	if renamePermission(routes, oldName, newName) > 0 {
+		if err := loader.write(routes); err != nil {
+			return 0, err
+		}
+	}
+
+	updated := loader.table.RenamePermission(oldName, newName)
+
+	loader.logger.Infof("[RoutesLoader] Permission '%s' renamed to '%s' in %d routes!", oldName, newName, updated)
+
+	return updated, nil
+}
+
+// write replaces the config file with routes, one per line like the
+// embedded config. The file is renamed into place so a crash never leaves
+// it half written.
+func (loader *loader) write(routes []Route) error {
+	var data bytes.Buffer
+	data.WriteString("[\n")
+	for i, route := range routes {
+		line, err := json.Marshal(route)
+		if err != nil {
+			return err
+		}
+
+		data.WriteString("    ")
+		data.Write(line)
+		if i < len(routes)-1 {
+			data.WriteString(",")
+		}
+
+		data.WriteString("\n")
+	}
+
+	data.WriteString("]\n")
+
+	if err := os.MkdirAll(filepath.Dir(loader.options.ConfigPath), 0o755); err != nil {
+		return err
+	}
+
+	temporaryPath := loader.options.ConfigPath + ".tmp"
+	if err := os.WriteFile(temporaryPath, data.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, loader.options.ConfigPath)
+}
+
+func NewLoader(
+	logger logger.Logger,
+	options Options,
+	table Table,
+	permissionExists func(name string) bool,
+) Loader {
+	return &loader{
+		logger:           logger,
+		options:          options,
+		table:            table,
+		permissionExists: permissionExists,
+		registered:       []Route{},
+	}
+}
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type testLogger struct{}
+
+func (testLogger) Debugf(format string, args ...any) {}
+func (testLogger) Infof(format string, args ...any)  {}
+
+func TestLoaderKeepsRenamedPermissionsAfterReloading(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "routes.json")
+	config := `[
+    {"method": "GET", "path": "/reports/", "permissions": ["reports_view", "reports_full"], "auth_required": true},
+    {"method": "POST", "path": "/reports/", "permissions": ["reports_full"], "auth_required": true}
+]
+`
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	permissions := []string{"reports_view", "reports_full"}
+	table := NewTable()
+	loader := NewLoader(testLogger{}, Options{ConfigPath: configPath}, table, func(name string) bool {
+		return slices.Contains(permissions, name)
+	})
+	loader.Register("GET", "/reports/")
+	loader.Register("POST", "/reports/")
+
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	permissions = []string{"reports_read", "reports_full"}
+	updated, err := loader.RenamePermission("reports_view", "reports_read")
+	if err != nil {
+		t.Fatalf("RenamePermission: %v", err)
+	}
+
+	if updated != 1 {
+		t.Errorf("RenamePermission updated %d routes, want 1", updated)
+	}
+
+	// A reload reads the config file again, which fails if it still
+	// requires the old name.
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Load after renaming: %v", err)
+	}
+
+	route, _ := table.Get("GET", "/reports/")
+	if !slices.Equal(route.Permissions, []string{"reports_read", "reports_full"}) {
+		t.Errorf("GET /reports/ requires %v, want [reports_read reports_full]", route.Permissions)
+	}
+}
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+type Route struct {
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+	Permissions  []string `json:"permissions"`
+	AuthRequired bool     `json:"auth_required"`
+}
+
+// Table holds the permissions required by every route. Consumers must look
+// routes up on each request, so changes apply without registering them again.
+type Table interface {
+	Replace(routes []Route)
+	Get(method, path string) (Route, bool)
+
+	// Match finds the route whose template matches a concrete path, such as
+	// /users/id/5 for /users/id/:id, with or without the trailing slash.
+	Match(method, path string) (Route, bool)
+	Routes() []Route
+	RequiresPermission(name string) bool
+	RenamePermission(oldName, newName string) int
+}
+
+type table struct {
+	mutex  sync.RWMutex
+	routes []Route
+}
+
+func (table *table) Replace(routes []Route) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	table.routes = []Route{}
+	for _, route := range routes {
+		route.Permissions = slices.Clone(route.Permissions)
+		table.routes = append(table.routes, route)
+	}
+}
+
+func (table *table) Get(method, path string) (Route, bool) {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	for _, route := range table.routes {
+		if route.Method == method && route.Path == path {
+			route.Permissions = slices.Clone(route.Permissions)
+			return route, true
+		}
+	}
+
+	return Route{}, false
+}
+
+// segmentKind orders the kinds of template segments the way gin prefers
+// them when several templates match a path.
+type segmentKind int
+
+const (
+	catchAllSegment segmentKind = iota
+	paramSegment
+	staticSegment
+)
+
+func kindOf(segment string) segmentKind {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return catchAllSegment
+	case strings.HasPrefix(segment, ":"):
+		return paramSegment
+	}
+
+	return staticSegment
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
+
+// matchSegments returns the kinds of the template segments used to match
+// the path segments, or false when the template does not match them.
+func matchSegments(template, path []string) ([]segmentKind, bool) {
+	kinds := []segmentKind{}
+	for i, segment := range template {
+		kind := kindOf(segment)
+		if kind == catchAllSegment {
+			return append(kinds, kind), true
+		}
+
+		if i >= len(path) || (kind == staticSegment && segment != path[i]) {
+			return nil, false
+		}
+
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, len(template) == len(path)
+}
+
+// moreSpecific reports whether a template matched with kinds is preferred to
+// one matched with other, comparing their segments from the start.
+func moreSpecific(kinds, other []segmentKind) bool {
+	for i := 0; i < len(kinds) && i < len(other); i++ {
+		if kinds[i] != other[i] {
+			return kinds[i] > other[i]
+		}
+	}
+
+	return len(kinds) > len(other)
+}
+
+func (table *table) Match(method, path string) (Route, bool) {
+	path, _, _ = strings.Cut(path, "?")
+	segments := splitPath(path)
+
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	var matched *Route
+	var matchedKinds []segmentKind
+	for i, route := range table.routes {
+		if route.Method != method {
+			continue
+		}
+
+		kinds, ok := matchSegments(splitPath(route.Path), segments)
+		if ok && (matched == nil || moreSpecific(kinds, matchedKinds)) {
+			matched = &table.routes[i]
+			matchedKinds = kinds
+		}
+	}
+
+	if matched == nil {
+		return Route{}, false
+	}
+
+	route := *matched
+	route.Permissions = slices.Clone(route.Permissions)
+
+	return route, true
+}
+
+func (table *table) Routes() []Route {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	routes := []Route{}
+	for _, route := range table.routes {
+		route.Permissions = slices.Clone(route.Permissions)
+		routes = append(routes, route)
+	}
+
+	return routes
+}
+
+// RequiresPermission reports whether any route requires the permission name.
+func (table *table) RequiresPermission(name string) bool {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+
+	for _, route := range table.routes {
+		if slices.ContainsFunc(route.Permissions, func(permission string) bool {
+			return strings.EqualFold(permission, name)
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RenamePermission replaces oldName with newName in the requirements of every
+// route and returns how many routes changed.
+func (table *table) RenamePermission(oldName, newName string) int {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+
+	return renamePermission(table.routes, oldName, newName)
+}
+
+func renamePermission(routes []Route, oldName, newName string) int {
+	updated := 0
+	for i := range routes {
+		changed := false
+		for j, permission := range routes[i].Permissions {
+			if strings.EqualFold(permission, oldName) {
+				routes[i].Permissions[j] = newName
+				changed = true
+			}
+		}
+
+		if changed {
+			updated++
+		}
+	}
+
+	return updated
+}
+
+func NewTable() Table {
+	return &table{
+		routes: []Route{},
+	}
+}
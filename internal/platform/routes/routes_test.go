@@ -0,0 +1,55 @@
+package routes
+
+import "testing"
+
+func TestTableMatch(t *testing.T) {
+	table := NewTable()
+	table.Replace([]Route{
+		{Method: "GET", Path: "/users/", Permissions: []string{"users_read"}},
+		{Method: "GET", Path: "/users/id/:id", Permissions: []string{"users_read"}},
+		{Method: "GET", Path: "/users/export", Permissions: []string{"users_full"}},
+		{Method: "GET", Path: "/users/:publicId", Permissions: []string{"users_read"}},
+		{Method: "DELETE", Path: "/users/username/:username/", Permissions: []string{"users_write"}},
+		{Method: "GET", Path: "/files/*path", Permissions: []string{"files_read"}},
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/users/", "/users/"},
+		{"GET", "/users", "/users/"},
+		{"GET", "/users/id/5", "/users/id/:id"},
+		{"GET", "/users/id/5/", "/users/id/:id"},
+		{"GET", "/users/id/:id", "/users/id/:id"},
+		{"GET", "/users/export", "/users/export"},
+		{"GET", "/users/0188d0c2", "/users/:publicId"},
+		{"GET", "/users/id/5?fields=name", "/users/id/:id"},
+		{"DELETE", "/users/username/dsolarte", "/users/username/:username/"},
+		{"DELETE", "/users/username/dsolarte/", "/users/username/:username/"},
+		{"GET", "/files/a/b/c", "/files/*path"},
+	}
+
+	for _, test := range tests {
+		route, ok := table.Match(test.method, test.path)
+		if !ok {
+			t.Errorf("Match(%s, %s) found no route, want %s", test.method, test.path, test.want)
+			continue
+		}
+
+		if route.Path != test.want {
+			t.Errorf("Match(%s, %s) = %s, want %s", test.method, test.path, route.Path, test.want)
+		}
+	}
+
+	for _, path := range []string{"/users/id/5/extra", "/", "/groups/"} {
+		if route, ok := table.Match("GET", path); ok {
+			t.Errorf("Match(GET, %s) = %s, want no route", path, route.Path)
+		}
+	}
+
+	if _, ok := table.Match("POST", "/users/id/5"); ok {
+		t.Errorf("Match(POST, /users/id/5) found a route of another method")
+	}
+}
@@ -0,0 +1,75 @@
+package uuid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+var generator struct {
+	sync.Mutex
+
+	lastMillis int64
+	counter    uint16
+}
+
+// NewV7 returns a random UUIDv7 (RFC 9562). UUIDs generated in the same
+// millisecond use the 12 bits after the version as a counter, so the UUIDs of
+// a process sort as strings in the order they were generated.
+func NewV7() (string, error) {
+	var value [16]byte
+	if _, err := rand.Read(value[:]); err != nil {
+		return "", err
+	}
+
+	generator.Lock()
+	millis := time.Now().UnixMilli()
+	if millis > generator.lastMillis {
+		// Starting below the middle leaves room for the next UUIDs of the
+		// same millisecond.
+		generator.lastMillis = millis
+		generator.counter = uint16(value[6]&0x07)<<8 | uint16(value[7])
+	} else if generator.counter++; generator.counter > 0x0fff {
+		generator.lastMillis++
+		generator.counter = 0
+	}
+
+	millis = generator.lastMillis
+	counter := generator.counter
+	generator.Unlock()
+
+	for i := 5; i >= 0; i-- {
+		value[i] = byte(millis)
+		millis >>= 8
+	}
+
+	value[6] = 0x70 | byte(counter>>8)
+	value[7] = byte(counter)
+	value[8] = 0x80 | value[8]&0x3f
+
+	return format(value), nil
+}
+
+func format(value [16]byte) string {
+	buffer := make([]byte, 36)
+	hex.Encode(buffer[0:8], value[0:4])
+	buffer[8] = '-'
+	hex.Encode(buffer[9:13], value[4:6])
+	buffer[13] = '-'
+	hex.Encode(buffer[14:18], value[6:8])
+	buffer[18] = '-'
+	hex.Encode(buffer[19:23], value[8:10])
+	buffer[23] = '-'
+	hex.Encode(buffer[24:], value[10:])
+
+	return string(buffer)
+}
+
+// IsValidV7 reports whether value is a UUIDv7 in its lowercase canonical form.
+func IsValidV7(value string) bool {
+	return pattern.MatchString(value)
+}
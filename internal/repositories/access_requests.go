@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"go-crud-gin/internal/models"
+	"slices"
+	"sync"
+)
+
+// AccessRequestsRepository keeps the access requests, which are never
+// deleted, only resolved.
+type AccessRequestsRepository interface {
+	Create(accessRequest models.AccessRequest) (int, error)
+	GetByID(id int) *models.AccessRequest
+	GetAll() []models.AccessRequest
+	Update(accessRequest models.AccessRequest) error
+}
+
+type memoryAccessRequestsRepository struct {
+	mutex  sync.RWMutex
+	lastID int
+
+	// ids keeps the creation order for GetAll.
+	ids            []int
+	accessRequests map[int]models.AccessRequest
+}
+
+// cloneAccessRequest copies the approvers too, so callers cannot change the
+// stored request.
+func cloneAccessRequest(accessRequest models.AccessRequest) models.AccessRequest {
+	accessRequest.ApprovedBy = slices.Clone(accessRequest.ApprovedBy)
+	return accessRequest
+}
+
+func (repository *memoryAccessRequestsRepository) Create(accessRequest models.AccessRequest) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.lastID++
+	accessRequest.ID = repository.lastID
+
+	repository.ids = append(repository.ids, accessRequest.ID)
+	repository.accessRequests[accessRequest.ID] = cloneAccessRequest(accessRequest)
+
+	return accessRequest.ID, nil
+}
+
+func (repository *memoryAccessRequestsRepository) GetByID(id int) *models.AccessRequest {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	accessRequest, ok := repository.accessRequests[id]
+	if !ok {
+		return nil
+	}
+
+	accessRequest = cloneAccessRequest(accessRequest)
+
+	return &accessRequest
+}
+
+func (repository *memoryAccessRequestsRepository) GetAll() []models.AccessRequest {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	accessRequests := make([]models.AccessRequest, len(repository.ids))
+	for i, id := range repository.ids {
+		accessRequests[i] = cloneAccessRequest(repository.accessRequests[id])
+	}
+
+	return accessRequests
+}
+
+func (repository *memoryAccessRequestsRepository) Update(accessRequest models.AccessRequest) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.accessRequests[accessRequest.ID]; !ok {
+		return ErrNotFound
+	}
+
+	repository.accessRequests[accessRequest.ID] = cloneAccessRequest(accessRequest)
+
+	return nil
+}
+
+func NewMemoryAccessRequestsRepository() AccessRequestsRepository {
+	return newMemoryAccessRequestsRepository()
+}
+
+func newMemoryAccessRequestsRepository() *memoryAccessRequestsRepository {
+	return &memoryAccessRequestsRepository{
+		ids:            []int{},
+		accessRequests: map[int]models.AccessRequest{},
+	}
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"os"
+)
+
+// ErrCorrupted is returned when a snapshot or a log record does not match its
+// checksum.
+var ErrCorrupted = errors.New("corrupted storage file")
+
+// errTornFrame is returned for a frame that ends past the data, as left by a
+// write interrupted by a crash.
+var errTornFrame = errors.New("torn frame")
+
+// Every log record and snapshot is framed as the length and the CRC-32C
+// checksum of its JSON payload, both as big-endian uint32, followed by the
+// payload itself. The smallest payload is an empty JSON object.
+const (
+	frameHeaderSize = 8
+	minFrameSize    = frameHeaderSize + len("{}")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+func encodeFrame(value any) ([]byte, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crcTable))
+	copy(frame[frameHeaderSize:], payload)
+
+	return frame, nil
+}
+
+// decodeFrame decodes the frame at the start of data into value and returns
+// the size of the frame.
+func decodeFrame(data []byte, value any) (int, error) {
+	if len(data) < frameHeaderSize {
+		return 0, errTornFrame
+	}
+
+	length := int(binary.BigEndian.Uint32(data[0:4]))
+	if length > len(data)-frameHeaderSize {
+		if !isTornTail(data) {
+			return 0, ErrCorrupted
+		}
+
+		return 0, errTornFrame
+	}
+
+	size := frameHeaderSize + length
+	payload := data[frameHeaderSize:size]
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(data[4:8]) {
+		return size, ErrCorrupted
+	}
+
+	if err := json.Unmarshal(payload, value); err != nil {
+		return size, errors.Join(ErrCorrupted, err)
+	}
+
+	return size, nil
+}
+
+// isValidFrame reports whether a complete frame with a matching checksum
+// starts at the start of data.
+func isValidFrame(data []byte) bool {
+	if len(data) < minFrameSize {
+		return false
+	}
+
+	length := int(binary.BigEndian.Uint32(data[0:4]))
+	if length < minFrameSize-frameHeaderSize || length > len(data)-frameHeaderSize {
+		return false
+	}
+
+	return crc32.Checksum(data[frameHeaderSize:frameHeaderSize+length], crcTable) == binary.BigEndian.Uint32(data[4:8])
+}
+
+// isTornTail reports whether data, which starts with a frame that runs past
+// its end, is what a crash in the middle of appending that frame leaves. A
+// corrupted length in the middle of the data runs past its end too, but the
+// complete frames written after it are still there, so a torn frame is only
+// accepted when no complete frame follows its header.
+func isTornTail(data []byte) bool {
+	rest := data[min(len(data), frameHeaderSize):]
+	for offset := 0; offset+minFrameSize <= len(rest); offset++ {
+		if isValidFrame(rest[offset:]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeFileSync writes data to path and waits until it is on disk.
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// syncDir waits until the entries of dir, such as a renamed file, are on disk.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return file.Sync()
+}
@@ -0,0 +1,905 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+type FileStoreOptions struct {
+	// Dir holds the snapshot and the write-ahead log. It is created when it
+	// does not exist.
+	Dir string
+
+	// SnapshotEvery is the number of log records after which the data is
+	// compacted into a new snapshot and the log emptied. 0 never compacts.
+	SnapshotEvery int
+}
+
+func DefaultFileStoreOptions() FileStoreOptions {
+	return FileStoreOptions{
+		Dir:           "data",
+		SnapshotEvery: 1000,
+	}
+}
+
+const (
+	snapshotFileName = "snapshot"
+	logFileName      = "wal.log"
+)
+
+const (
+	operationCreateOrganization     = "create_organization"
+	operationCreateUser             = "create_user"
+	operationUpdateUser             = "update_user"
+	operationDeleteUser             = "delete_user"
+	operationSaveUserAttribute      = "save_user_attribute"
+	operationDeleteUserAttribute    = "delete_user_attribute"
+	operationCreatePermission       = "create_permission"
+	operationUpdatePermission       = "update_permission"
+	operationDeletePermission       = "delete_permission"
+	operationCreateGroup            = "create_group"
+	operationDeleteGroup            = "delete_group"
+	operationAddGroupMembers        = "add_group_members"
+	operationRemoveGroupMember      = "remove_group_member"
+	operationDeleteMemberships      = "delete_memberships"
+	operationApplyUserGrants        = "apply_user_grants"
+	operationDeleteUserGrants       = "delete_user_grants"
+	operationAddGroupGrant          = "add_group_grant"
+	operationRemoveGroupGrant       = "remove_group_grant"
+	operationDeleteGroupGrants      = "delete_group_grants"
+	operationDeletePermissionGrants = "delete_permission_grants"
+	operationCreateAccessRequest    = "create_access_request"
+	operationUpdateAccessRequest    = "update_access_request"
+	operationSetSetting             = "set_setting"
+)
+
+// storedUser keeps the fields the JSON of the user hides from the API.
+type storedUser struct {
+	models.User
+	ID           int    `json:"internal_id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func newStoredUser(user models.User) *storedUser {
+	return &storedUser{
+		User:         user,
+		ID:           user.ID,
+		PasswordHash: user.PasswordHash,
+	}
+}
+
+func (stored storedUser) model() models.User {
+	user := stored.User
+	user.ID = stored.ID
+	user.PasswordHash = stored.PasswordHash
+
+	return user
+}
+
+// storedPermission keeps the fields the JSON of the permission hides from the
+// API.
+type storedPermission struct {
+	models.Permission
+	ID        int  `json:"internal_id"`
+	Deletable bool `json:"deletable"`
+}
+
+func newStoredPermission(permission models.Permission) *storedPermission {
+	return &storedPermission{
+		Permission: permission,
+		ID:         permission.ID,
+		Deletable:  permission.Deletable,
+	}
+}
+
+func (stored storedPermission) model() models.Permission {
+	permission := stored.Permission
+	permission.ID = stored.ID
+	permission.Deletable = stored.Deletable
+
+	return permission
+}
+
+// logRecord is a change to the data. Sequence grows with every record, so
+// the ones already in the snapshot are skipped when the log is replayed.
+type logRecord struct {
+	Sequence          uint64                          `json:"sequence"`
+	Operation         string                          `json:"operation"`
+	ID                int                             `json:"id,omitempty"`
+	Organization      *models.Organization            `json:"organization,omitempty"`
+	User              *storedUser                     `json:"user,omitempty"`
+	UserAttribute     *models.UserAttributeDefinition `json:"user_attribute,omitempty"`
+	Permission        *storedPermission               `json:"permission,omitempty"`
+	Group             *models.Group                   `json:"group,omitempty"`
+	GroupID           int                             `json:"group_id,omitempty"`
+	UserIDs           []int                           `json:"user_ids,omitempty"`
+	AddedUserGrants   []models.UserPermission         `json:"added_user_grants,omitempty"`
+	RemovedUserGrants []models.UserPermission         `json:"removed_user_grants,omitempty"`
+	GroupGrant        *models.GroupPermission         `json:"group_grant,omitempty"`
+	AccessRequest     *models.AccessRequest           `json:"access_request,omitempty"`
+	Setting           *setting                        `json:"setting,omitempty"`
+}
+
+type setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type snapshot struct {
+	Sequence            uint64                           `json:"sequence"`
+	LastOrganizationID  int                              `json:"last_organization_id"`
+	Organizations       []models.Organization            `json:"organizations"`
+	LastUserID          int                              `json:"last_user_id"`
+	Users               []storedUser                     `json:"users"`
+	UserAttributes      []models.UserAttributeDefinition `json:"user_attributes"`
+	LastPermissionID    int                              `json:"last_permission_id"`
+	Permissions         []storedPermission               `json:"permissions"`
+	LastGroupID         int                              `json:"last_group_id"`
+	Groups              []models.Group                   `json:"groups"`
+	GroupMembers        []models.GroupMember             `json:"group_members"`
+	UserGrants          []models.UserPermission          `json:"user_grants"`
+	GroupGrants         []models.GroupPermission         `json:"group_grants"`
+	LastAccessRequestID int                              `json:"last_access_request_id"`
+	AccessRequests      []models.AccessRequest           `json:"access_requests"`
+	Settings            map[string]string                `json:"settings"`
+}
+
+// fileStore keeps the data in the memory repositories and makes it durable
+// with a write-ahead log. Every change is appended to the log and synced to
+// disk before it is applied, so the changes that fail are replayed and fail
+// the same way.
+type fileStore struct {
+	logger  logger.Logger
+	options FileStoreOptions
+
+	// mutex serializes the writes, so the log keeps their order.
+	mutex    sync.Mutex
+	log      *os.File
+	sequence uint64
+	// records counts the log records written since the last snapshot.
+	records int
+	// err is set when the log could not be written; the log may end with a
+	// partial record, so no more writes are accepted.
+	err error
+
+	organizations  *memoryOrganizationsRepository
+	users          *memoryUsersRepository
+	userAttributes *memoryUserAttributesRepository
+	permissions    *memoryPermissionsRepository
+	groups         *memoryGroupsRepository
+	grants         *memoryGrantsRepository
+	accessRequests *memoryAccessRequestsRepository
+	settings       *memorySettingsRepository
+}
+
+func (store *fileStore) Organizations() OrganizationsRepository {
+	return &fileOrganizationsRepository{
+		memoryOrganizationsRepository: store.organizations,
+		store:                         store,
+	}
+}
+
+func (store *fileStore) Users() UsersRepository {
+	return &fileUsersRepository{
+		memoryUsersRepository: store.users,
+		store:                 store,
+	}
+}
+
+func (store *fileStore) UserAttributes() UserAttributesRepository {
+	return &fileUserAttributesRepository{
+		memoryUserAttributesRepository: store.userAttributes,
+		store:                          store,
+	}
+}
+
+func (store *fileStore) Permissions() PermissionsRepository {
+	return &filePermissionsRepository{
+		memoryPermissionsRepository: store.permissions,
+		store:                       store,
+	}
+}
+
+func (store *fileStore) Groups() GroupsRepository {
+	return &fileGroupsRepository{
+		memoryGroupsRepository: store.groups,
+		store:                  store,
+	}
+}
+
+func (store *fileStore) Grants() GrantsRepository {
+	return &fileGrantsRepository{
+		memoryGrantsRepository: store.grants,
+		store:                  store,
+	}
+}
+
+func (store *fileStore) AccessRequests() AccessRequestsRepository {
+	return &fileAccessRequestsRepository{
+		memoryAccessRequestsRepository: store.accessRequests,
+		store:                          store,
+	}
+}
+
+func (store *fileStore) Settings() SettingsRepository {
+	return &fileSettingsRepository{
+		memorySettingsRepository: store.settings,
+		store:                    store,
+	}
+}
+
+// apply changes the memory repositories as record says and returns the ID
+// of the created entity, if any.
+func (store *fileStore) apply(record logRecord) (int, error) {
+	switch record.Operation {
+	case operationCreateOrganization:
+		return store.organizations.Create(*record.Organization)
+	case operationCreateUser:
+		return store.users.Create(record.User.model())
+	case operationUpdateUser:
+		return 0, store.users.Update(record.User.model())
+	case operationDeleteUser:
+		return 0, store.users.Delete(record.ID)
+	case operationSaveUserAttribute:
+		return 0, store.userAttributes.Save(*record.UserAttribute)
+	case operationDeleteUserAttribute:
+		return 0, store.userAttributes.Delete(record.UserAttribute.OrganizationID, record.UserAttribute.Name)
+	case operationCreatePermission:
+		return store.permissions.Create(record.Permission.model())
+	case operationUpdatePermission:
+		return 0, store.permissions.Update(record.Permission.model())
+	case operationDeletePermission:
+		return 0, store.permissions.Delete(record.ID)
+	case operationCreateGroup:
+		return store.groups.Create(*record.Group)
+	case operationDeleteGroup:
+		return 0, store.groups.Delete(record.ID)
+	case operationAddGroupMembers:
+		return 0, store.groups.AddMembers(record.GroupID, record.UserIDs)
+	case operationRemoveGroupMember:
+		return 0, store.groups.RemoveMember(record.GroupID, record.ID)
+	case operationDeleteMemberships:
+		return 0, store.groups.DeleteMemberships(record.ID)
+	case operationApplyUserGrants:
+		return 0, store.grants.ApplyUserGrants(record.AddedUserGrants, record.RemovedUserGrants)
+	case operationDeleteUserGrants:
+		return 0, store.grants.DeleteUserGrants(record.ID)
+	case operationAddGroupGrant:
+		return 0, store.grants.AddGroupGrant(*record.GroupGrant)
+	case operationRemoveGroupGrant:
+		return 0, store.grants.RemoveGroupGrant(*record.GroupGrant)
+	case operationDeleteGroupGrants:
+		return 0, store.grants.DeleteGroupGrants(record.ID)
+	case operationDeletePermissionGrants:
+		return 0, store.grants.DeletePermissionGrants(record.ID)
+	case operationCreateAccessRequest:
+		return store.accessRequests.Create(*record.AccessRequest)
+	case operationUpdateAccessRequest:
+		return 0, store.accessRequests.Update(*record.AccessRequest)
+	case operationSetSetting:
+		return 0, store.settings.Set(record.Setting.Name, record.Setting.Value)
+	}
+
+	return 0, fmt.Errorf("unknown operation %q: %w", record.Operation, ErrCorrupted)
+}
+
+// write logs record and applies it, compacting the log when it grew enough.
+func (store *fileStore) write(record logRecord) (int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.err != nil {
+		return 0, store.err
+	}
+
+	record.Sequence = store.sequence + 1
+
+	frame, err := encodeFrame(record)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := store.log.Write(frame); err != nil {
+		store.err = fmt.Errorf("write-ahead log not written: %w", err)
+		return 0, store.err
+	}
+
+	if err := store.log.Sync(); err != nil {
+		store.err = fmt.Errorf("write-ahead log not synced: %w", err)
+		return 0, store.err
+	}
+
+	store.sequence = record.Sequence
+	store.records++
+
+	id, err := store.apply(record)
+
+	if store.options.SnapshotEvery > 0 && store.records >= store.options.SnapshotEvery {
+		if err := store.snapshot(); err != nil {
+			// The log still has every record, so this only delays the
+			// compaction until the next SnapshotEvery records.
+			store.logger.Infof("[FileStore] Snapshot not written: %v", err)
+			store.records = 0
+		}
+	}
+
+	return id, err
+}
+
+// snapshot writes the data to a new snapshot, replacing the previous one
+// only once it is on disk, and then empties the log.
+func (store *fileStore) snapshot() error {
+	frame, err := encodeFrame(store.capture())
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(store.options.Dir, snapshotFileName)
+	if err := writeFileSync(path+".tmp", frame); err != nil {
+		return err
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	if err := syncDir(store.options.Dir); err != nil {
+		return err
+	}
+
+	// A crash before the log is emptied is harmless, since its records are
+	// not newer than the snapshot.
+	if err := store.log.Truncate(0); err != nil {
+		return err
+	}
+
+	if err := store.log.Sync(); err != nil {
+		return err
+	}
+
+	store.records = 0
+	store.logger.Infof("[FileStore] Snapshot written at record %d!", store.sequence)
+
+	return nil
+}
+
+func (store *fileStore) capture() snapshot {
+	store.organizations.mutex.RLock()
+	defer store.organizations.mutex.RUnlock()
+
+	store.users.mutex.RLock()
+	defer store.users.mutex.RUnlock()
+
+	store.userAttributes.mutex.RLock()
+	defer store.userAttributes.mutex.RUnlock()
+
+	store.permissions.mutex.RLock()
+	defer store.permissions.mutex.RUnlock()
+
+	store.groups.mutex.RLock()
+	defer store.groups.mutex.RUnlock()
+
+	store.grants.mutex.RLock()
+	defer store.grants.mutex.RUnlock()
+
+	store.accessRequests.mutex.RLock()
+	defer store.accessRequests.mutex.RUnlock()
+
+	store.settings.mutex.RLock()
+	defer store.settings.mutex.RUnlock()
+
+	data := snapshot{
+		Sequence:            store.sequence,
+		LastOrganizationID:  store.organizations.lastID,
+		Organizations:       make([]models.Organization, len(store.organizations.ids)),
+		LastUserID:          store.users.lastID,
+		Users:               make([]storedUser, len(store.users.ids)),
+		UserAttributes:      slices.Clone(store.userAttributes.definitions),
+		LastPermissionID:    store.permissions.lastID,
+		Permissions:         make([]storedPermission, len(store.permissions.ids)),
+		LastGroupID:         store.groups.lastID,
+		Groups:              make([]models.Group, len(store.groups.ids)),
+		GroupMembers:        []models.GroupMember{},
+		UserGrants:          []models.UserPermission{},
+		GroupGrants:         []models.GroupPermission{},
+		LastAccessRequestID: store.accessRequests.lastID,
+		AccessRequests:      make([]models.AccessRequest, len(store.accessRequests.ids)),
+		Settings:            maps.Clone(store.settings.settings),
+	}
+
+	for i, id := range store.organizations.ids {
+		data.Organizations[i] = store.organizations.organizations[id]
+	}
+
+	for i, id := range store.users.ids {
+		data.Users[i] = *newStoredUser(store.users.users[id])
+	}
+
+	for i, id := range store.permissions.ids {
+		data.Permissions[i] = *newStoredPermission(store.permissions.permissions[id])
+	}
+
+	for i, id := range store.groups.ids {
+		data.Groups[i] = store.groups.groups[id]
+	}
+
+	for _, link := range store.groups.members.all() {
+		data.GroupMembers = append(data.GroupMembers, models.GroupMember{
+			GroupID: link[0],
+			UserID:  link[1],
+		})
+	}
+
+	for _, link := range store.grants.userGrants.all() {
+		data.UserGrants = append(data.UserGrants, models.UserPermission{
+			UserID:       link[0],
+			PermissionID: link[1],
+		})
+	}
+
+	for _, link := range store.grants.groupGrants.all() {
+		data.GroupGrants = append(data.GroupGrants, models.GroupPermission{
+			GroupID:      link[0],
+			PermissionID: link[1],
+		})
+	}
+
+	for i, id := range store.accessRequests.ids {
+		data.AccessRequests[i] = store.accessRequests.accessRequests[id]
+	}
+
+	return data
+}
+
+// restore fills the empty memory repositories with the snapshot.
+func (store *fileStore) restore(data snapshot) {
+	for _, organization := range data.Organizations {
+		store.organizations.ids = append(store.organizations.ids, organization.ID)
+		store.organizations.index(organization)
+	}
+
+	for _, stored := range data.Users {
+		user := stored.model()
+		store.users.ids = append(store.users.ids, user.ID)
+		store.users.index(user)
+	}
+
+	if data.UserAttributes != nil {
+		store.userAttributes.definitions = data.UserAttributes
+	}
+
+	for _, stored := range data.Permissions {
+		permission := stored.model()
+		store.permissions.ids = append(store.permissions.ids, permission.ID)
+		store.permissions.index(permission)
+	}
+
+	for _, group := range data.Groups {
+		store.groups.ids = append(store.groups.ids, group.ID)
+		store.groups.index(group)
+	}
+
+	for _, member := range data.GroupMembers {
+		store.groups.members.add(member.GroupID, member.UserID)
+	}
+
+	for _, grant := range data.UserGrants {
+		store.grants.userGrants.add(grant.UserID, grant.PermissionID)
+	}
+
+	for _, grant := range data.GroupGrants {
+		store.grants.groupGrants.add(grant.GroupID, grant.PermissionID)
+	}
+
+	for _, accessRequest := range data.AccessRequests {
+		store.accessRequests.ids = append(store.accessRequests.ids, accessRequest.ID)
+		store.accessRequests.accessRequests[accessRequest.ID] = accessRequest
+	}
+
+	for name, value := range data.Settings {
+		store.settings.settings[name] = value
+	}
+
+	store.organizations.lastID = data.LastOrganizationID
+	store.users.lastID = data.LastUserID
+	store.permissions.lastID = data.LastPermissionID
+	store.groups.lastID = data.LastGroupID
+	store.accessRequests.lastID = data.LastAccessRequestID
+	store.sequence = data.Sequence
+}
+
+func (store *fileStore) loadSnapshot() error {
+	path := filepath.Join(store.options.Dir, snapshotFileName)
+
+	// A leftover temporary snapshot was never completed.
+	if err := os.Remove(path + ".tmp"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var value snapshot
+	size, err := decodeFrame(data, &value)
+	if err == nil && size != len(data) {
+		err = ErrCorrupted
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	store.restore(value)
+
+	return nil
+}
+
+// replay applies the log records newer than the snapshot and returns the
+// size of the valid part of the log. A partial or corrupted last record is
+// what a crash in the middle of a write leaves, so it is dropped; any other
+// corruption stops the recovery.
+func (store *fileStore) replay(data []byte) (int, error) {
+	offset := 0
+	for offset < len(data) {
+		var record logRecord
+		size, err := decodeFrame(data[offset:], &record)
+		if errors.Is(err, errTornFrame) || (errors.Is(err, ErrCorrupted) && offset+size == len(data)) {
+			store.logger.Infof("[FileStore] Dropped the incomplete last record of %s at offset %d", logFileName, offset)
+			return offset, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("%s at offset %d: %w", logFileName, offset, err)
+		}
+
+		offset += size
+
+		if record.Sequence <= store.sequence {
+			continue
+		}
+
+		// The changes that failed when they were written fail again.
+		_, err = store.apply(record)
+		if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrAlreadyExists) {
+			return 0, fmt.Errorf("%s at offset %d: %w", logFileName, offset-size, err)
+		}
+
+		store.sequence = record.Sequence
+		store.records++
+	}
+
+	return offset, nil
+}
+
+func (store *fileStore) openLog() error {
+	log, err := os.OpenFile(filepath.Join(store.options.Dir, logFileName), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(log)
+	if err != nil {
+		log.Close()
+		return err
+	}
+
+	valid, err := store.replay(data)
+	if err != nil {
+		log.Close()
+		return err
+	}
+
+	if valid < len(data) {
+		if err := log.Truncate(int64(valid)); err != nil {
+			log.Close()
+			return err
+		}
+
+		if err := log.Sync(); err != nil {
+			log.Close()
+			return err
+		}
+	}
+
+	store.log = log
+
+	return nil
+}
+
+// NewFileStore returns a store that keeps everything in memory and on disk
+// in options.Dir, recovering the data written there before.
+func NewFileStore(logger logger.Logger, options FileStoreOptions) (Store, error) {
+	if err := os.MkdirAll(options.Dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	store := &fileStore{
+		logger:  logger,
+		options: options,
+
+		organizations:  newMemoryOrganizationsRepository(),
+		users:          newMemoryUsersRepository(),
+		userAttributes: newMemoryUserAttributesRepository(),
+		permissions:    newMemoryPermissionsRepository(),
+		groups:         newMemoryGroupsRepository(),
+		grants:         newMemoryGrantsRepository(),
+		accessRequests: newMemoryAccessRequestsRepository(),
+		settings:       newMemorySettingsRepository(),
+	}
+
+	if err := store.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	if err := store.openLog(); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("[FileStore] %d organizations, %d users and %d permissions loaded from %s!", len(store.organizations.ids), len(store.users.ids), len(store.permissions.ids), options.Dir)
+
+	return store, nil
+}
+
+type fileOrganizationsRepository struct {
+	*memoryOrganizationsRepository
+	store *fileStore
+}
+
+func (repository *fileOrganizationsRepository) Create(organization models.Organization) (int, error) {
+	return repository.store.write(logRecord{
+		Operation:    operationCreateOrganization,
+		Organization: &organization,
+	})
+}
+
+type fileUsersRepository struct {
+	*memoryUsersRepository
+	store *fileStore
+}
+
+func (repository *fileUsersRepository) Create(user models.User) (int, error) {
+	return repository.store.write(logRecord{
+		Operation: operationCreateUser,
+		User:      newStoredUser(user),
+	})
+}
+
+func (repository *fileUsersRepository) Update(user models.User) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationUpdateUser,
+		User:      newStoredUser(user),
+	})
+
+	return err
+}
+
+func (repository *fileUsersRepository) Delete(id int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeleteUser,
+		ID:        id,
+	})
+
+	return err
+}
+
+type fileUserAttributesRepository struct {
+	*memoryUserAttributesRepository
+	store *fileStore
+}
+
+func (repository *fileUserAttributesRepository) Save(definition models.UserAttributeDefinition) error {
+	_, err := repository.store.write(logRecord{
+		Operation:     operationSaveUserAttribute,
+		UserAttribute: &definition,
+	})
+
+	return err
+}
+
+func (repository *fileUserAttributesRepository) Delete(organizationID int, name string) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeleteUserAttribute,
+		UserAttribute: &models.UserAttributeDefinition{
+			OrganizationID: organizationID,
+			Name:           name,
+		},
+	})
+
+	return err
+}
+
+type filePermissionsRepository struct {
+	*memoryPermissionsRepository
+	store *fileStore
+}
+
+func (repository *filePermissionsRepository) Create(permission models.Permission) (int, error) {
+	return repository.store.write(logRecord{
+		Operation:  operationCreatePermission,
+		Permission: newStoredPermission(permission),
+	})
+}
+
+func (repository *filePermissionsRepository) Update(permission models.Permission) error {
+	_, err := repository.store.write(logRecord{
+		Operation:  operationUpdatePermission,
+		Permission: newStoredPermission(permission),
+	})
+
+	return err
+}
+
+func (repository *filePermissionsRepository) Delete(id int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeletePermission,
+		ID:        id,
+	})
+
+	return err
+}
+
+type fileGroupsRepository struct {
+	*memoryGroupsRepository
+	store *fileStore
+}
+
+func (repository *fileGroupsRepository) Create(group models.Group) (int, error) {
+	return repository.store.write(logRecord{
+		Operation: operationCreateGroup,
+		Group:     &group,
+	})
+}
+
+func (repository *fileGroupsRepository) Delete(id int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeleteGroup,
+		ID:        id,
+	})
+
+	return err
+}
+
+func (repository *fileGroupsRepository) AddMembers(groupID int, userIDs []int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationAddGroupMembers,
+		GroupID:   groupID,
+		UserIDs:   userIDs,
+	})
+
+	return err
+}
+
+func (repository *fileGroupsRepository) RemoveMember(groupID, userID int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationRemoveGroupMember,
+		GroupID:   groupID,
+		ID:        userID,
+	})
+
+	return err
+}
+
+func (repository *fileGroupsRepository) DeleteMemberships(userID int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeleteMemberships,
+		ID:        userID,
+	})
+
+	return err
+}
+
+type fileGrantsRepository struct {
+	*memoryGrantsRepository
+	store *fileStore
+}
+
+func (repository *fileGrantsRepository) AddUserGrant(grant models.UserPermission) error {
+	return repository.ApplyUserGrants([]models.UserPermission{grant}, nil)
+}
+
+func (repository *fileGrantsRepository) RemoveUserGrant(grant models.UserPermission) error {
+	return repository.ApplyUserGrants(nil, []models.UserPermission{grant})
+}
+
+func (repository *fileGrantsRepository) ApplyUserGrants(added, removed []models.UserPermission) error {
+	_, err := repository.store.write(logRecord{
+		Operation:         operationApplyUserGrants,
+		AddedUserGrants:   added,
+		RemovedUserGrants: removed,
+	})
+
+	return err
+}
+
+func (repository *fileGrantsRepository) DeleteUserGrants(userID int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeleteUserGrants,
+		ID:        userID,
+	})
+
+	return err
+}
+
+func (repository *fileGrantsRepository) DeletePermissionGrants(permissionID int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeletePermissionGrants,
+		ID:        permissionID,
+	})
+
+	return err
+}
+
+func (repository *fileGrantsRepository) AddGroupGrant(grant models.GroupPermission) error {
+	_, err := repository.store.write(logRecord{
+		Operation:  operationAddGroupGrant,
+		GroupGrant: &grant,
+	})
+
+	return err
+}
+
+func (repository *fileGrantsRepository) RemoveGroupGrant(grant models.GroupPermission) error {
+	_, err := repository.store.write(logRecord{
+		Operation:  operationRemoveGroupGrant,
+		GroupGrant: &grant,
+	})
+
+	return err
+}
+
+func (repository *fileGrantsRepository) DeleteGroupGrants(groupID int) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationDeleteGroupGrants,
+		ID:        groupID,
+	})
+
+	return err
+}
+
+type fileAccessRequestsRepository struct {
+	*memoryAccessRequestsRepository
+	store *fileStore
+}
+
+func (repository *fileAccessRequestsRepository) Create(accessRequest models.AccessRequest) (int, error) {
+	return repository.store.write(logRecord{
+		Operation:     operationCreateAccessRequest,
+		AccessRequest: &accessRequest,
+	})
+}
+
+func (repository *fileAccessRequestsRepository) Update(accessRequest models.AccessRequest) error {
+	_, err := repository.store.write(logRecord{
+		Operation:     operationUpdateAccessRequest,
+		AccessRequest: &accessRequest,
+	})
+
+	return err
+}
+
+type fileSettingsRepository struct {
+	*memorySettingsRepository
+	store *fileStore
+}
+
+func (repository *fileSettingsRepository) Set(name, value string) error {
+	_, err := repository.store.write(logRecord{
+		Operation: operationSetSetting,
+		Setting: &setting{
+			Name:  name,
+			Value: value,
+		},
+	})
+
+	return err
+}
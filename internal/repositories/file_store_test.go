@@ -0,0 +1,386 @@
+package repositories
+
+import (
+	"encoding/binary"
+	"errors"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestFileStore opens a file store in dir that never takes snapshots, so
+// every change stays in the log.
+func newTestFileStore(dir string) (Store, error) {
+	return NewFileStore(logger.NewLocalLogger(), FileStoreOptions{Dir: dir})
+}
+
+func openTestFileStore(t *testing.T, options FileStoreOptions) Store {
+	t.Helper()
+
+	store, err := NewFileStore(logger.NewLocalLogger(), options)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	return store
+}
+
+func createTestUser(t *testing.T, store Store, username string) int {
+	t.Helper()
+
+	userID, err := store.Users().Create(models.User{
+		PublicID:     "public-" + username,
+		Username:     username,
+		PasswordHash: "hash-of-" + username,
+		Attributes:   map[string]any{"team": "core"},
+		Status:       models.UserActive,
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Create %s: %v", username, err)
+	}
+
+	return userID
+}
+
+func createTestPermission(t *testing.T, store Store, name string) int {
+	t.Helper()
+
+	permissionID, err := store.Permissions().Create(models.Permission{
+		PublicID:  "public-" + name,
+		Name:      name,
+		Deletable: true,
+	})
+	if err != nil {
+		t.Fatalf("Create %s: %v", name, err)
+	}
+
+	return permissionID
+}
+
+// writeTestLog creates three permissions in a new store and returns its
+// directory, the log and the offsets of its frames.
+func writeTestLog(t *testing.T) (string, []byte, []int) {
+	t.Helper()
+
+	dir := t.TempDir()
+	store, err := newTestFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	for _, name := range []string{"first", "second", "third"} {
+		createTestPermission(t, store, name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, logFileName))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	offsets := []int{}
+	for offset := 0; offset < len(data); {
+		var record logRecord
+		size, err := decodeFrame(data[offset:], &record)
+		if err != nil {
+			t.Fatalf("decodeFrame at %d: %v", offset, err)
+		}
+
+		offsets = append(offsets, offset)
+		offset += size
+	}
+
+	if len(offsets) != 3 {
+		t.Fatalf("got %d frames, want 3", len(offsets))
+	}
+
+	return dir, data, offsets
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestFileStoreKeepsDataAfterReopening(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestFileStore(t, FileStoreOptions{Dir: dir})
+
+	aliceID := createTestUser(t, store, "alice")
+	bobID := createTestUser(t, store, "bob")
+	readID := createTestPermission(t, store, "reports_read")
+	writeID := createTestPermission(t, store, "reports_write")
+
+	alice := store.Users().GetByID(aliceID)
+	alice.DisplayName = "Alice"
+	alice.PasswordHash = "new-hash"
+	if err := store.Users().Update(*alice); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	err := store.Grants().ApplyUserGrants([]models.UserPermission{
+		{UserID: aliceID, PermissionID: readID},
+		{UserID: aliceID, PermissionID: writeID},
+		{UserID: bobID, PermissionID: readID},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ApplyUserGrants: %v", err)
+	}
+
+	organizationID, err := store.Organizations().Create(models.Organization{Name: "acme"})
+	if err != nil {
+		t.Fatalf("Create organization: %v", err)
+	}
+
+	parentID, err := store.Groups().Create(models.Group{OrganizationID: organizationID, Name: "reporting"})
+	if err != nil {
+		t.Fatalf("Create group: %v", err)
+	}
+
+	groupID, err := store.Groups().Create(models.Group{OrganizationID: organizationID, Name: "analysts", ParentID: &parentID})
+	if err != nil {
+		t.Fatalf("Create group: %v", err)
+	}
+
+	if err := store.Groups().AddMembers(groupID, []int{aliceID, bobID}); err != nil {
+		t.Fatalf("AddMembers: %v", err)
+	}
+
+	if err := store.Groups().DeleteMemberships(bobID); err != nil {
+		t.Fatalf("DeleteMemberships: %v", err)
+	}
+
+	if err := store.Grants().RemoveUserGrant(models.UserPermission{UserID: aliceID, PermissionID: writeID}); err != nil {
+		t.Fatalf("RemoveUserGrant: %v", err)
+	}
+
+	if err := store.Users().Delete(bobID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if err := store.Grants().DeleteUserGrants(bobID); err != nil {
+		t.Fatalf("DeleteUserGrants: %v", err)
+	}
+
+	reopened := openTestFileStore(t, FileStoreOptions{Dir: dir})
+
+	got := reopened.Users().GetByUsername("ALICE")
+	if got == nil {
+		t.Fatalf("alice not found after reopening")
+	}
+
+	if got.ID != aliceID || got.PublicID != "public-alice" || got.DisplayName != "Alice" || got.PasswordHash != "new-hash" || got.Attributes["team"] != "core" {
+		t.Errorf("got alice %+v after reopening", *got)
+	}
+
+	if reopened.Users().GetByID(bobID) != nil {
+		t.Errorf("deleted user bob came back after reopening")
+	}
+
+	if got := reopened.Grants().GetUserGrantsForUser(aliceID); !slices.Equal(got, []models.UserPermission{{UserID: aliceID, PermissionID: readID}}) {
+		t.Errorf("got grants %v for alice, want only reports_read", got)
+	}
+
+	if got := reopened.Grants().GetUserGrantsForUser(bobID); len(got) != 0 {
+		t.Errorf("got grants %v for the deleted user bob", got)
+	}
+
+	if got := reopened.Organizations().GetByName("ACME"); got == nil || got.ID != organizationID {
+		t.Errorf("got organization %v after reopening, want ID %d", got, organizationID)
+	}
+
+	if got := reopened.Groups().GetByID(groupID); got == nil || got.ParentID == nil || *got.ParentID != parentID {
+		t.Errorf("got group %v after reopening, want parent %d", got, parentID)
+	}
+
+	if got := reopened.Groups().GetMemberIDs(groupID); !slices.Equal(got, []int{aliceID}) {
+		t.Errorf("got members %v after reopening, want only alice", got)
+	}
+
+	// IDs are never reused, even those of deleted users.
+	if carolID := createTestUser(t, reopened, "carol"); carolID != bobID+1 {
+		t.Errorf("carol got ID %d, want %d", carolID, bobID+1)
+	}
+}
+
+func TestFileStoreCompactsTheLogIntoSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	options := FileStoreOptions{Dir: dir, SnapshotEvery: 5}
+	store := openTestFileStore(t, options)
+
+	userID := createTestUser(t, store, "alice")
+	readID := createTestPermission(t, store, "reports_read")
+	if err := store.Grants().AddUserGrant(models.UserPermission{UserID: userID, PermissionID: readID}); err != nil {
+		t.Fatalf("AddUserGrant: %v", err)
+	}
+
+	groupID, err := store.Groups().Create(models.Group{OrganizationID: 1, Name: "analysts"})
+	if err != nil {
+		t.Fatalf("Create group: %v", err)
+	}
+
+	if err := store.Groups().AddMembers(groupID, []int{userID}); err != nil {
+		t.Fatalf("AddMembers: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, logFileName))
+	if err != nil {
+		t.Fatalf("Stat log: %v", err)
+	}
+
+	if info.Size() != 0 {
+		t.Errorf("log has %d bytes after the snapshot, want 0", info.Size())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("Stat snapshot: %v", err)
+	}
+
+	// This one is only in the log, after the snapshot.
+	writeID := createTestPermission(t, store, "reports_write")
+
+	reopened := openTestFileStore(t, options)
+
+	if reopened.Users().GetByUsername("alice") == nil {
+		t.Errorf("alice not found after reopening")
+	}
+
+	if reopened.Permissions().GetByID(writeID) == nil {
+		t.Errorf("permission written after the snapshot not found")
+	}
+
+	if !reopened.Grants().HasUserGrant(userID, readID) {
+		t.Errorf("grant from the snapshot not found")
+	}
+
+	if got := reopened.Groups().GetMemberIDs(groupID); !slices.Equal(got, []int{userID}) {
+		t.Errorf("got members %v from the snapshot, want alice", got)
+	}
+}
+
+func TestFileStoreSkipsRecordsInTheSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, logFileName)
+	store := openTestFileStore(t, FileStoreOptions{Dir: dir})
+
+	aliceID := createTestUser(t, store, "alice")
+	if err := store.Users().Delete(aliceID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	// A crash after writing the snapshot and before emptying the log leaves
+	// records the snapshot already has.
+	if err := store.(*fileStore).snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	writeTestFile(t, path, data)
+
+	reopened := openTestFileStore(t, FileStoreOptions{Dir: dir})
+	bobID := createTestUser(t, reopened, "bob")
+
+	reopened = openTestFileStore(t, FileStoreOptions{Dir: dir})
+
+	if reopened.Users().GetByUsername("alice") != nil {
+		t.Errorf("alice was created again by a record in the snapshot")
+	}
+
+	if bob := reopened.Users().GetByUsername("bob"); bob == nil || bob.ID != bobID || bobID != aliceID+1 {
+		t.Errorf("got bob %v, want ID %d", bob, aliceID+1)
+	}
+}
+
+func TestFileStoreDropsTornTail(t *testing.T) {
+	dir, data, offsets := writeTestLog(t)
+	path := filepath.Join(dir, logFileName)
+
+	// A crash in the middle of the last append leaves part of its payload.
+	writeTestFile(t, path, data[:len(data)-5])
+
+	store, err := newTestFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	if got := len(store.Permissions().GetAll()); got != 2 {
+		t.Errorf("got %d permissions, want 2", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	if info.Size() != int64(offsets[2]) {
+		t.Errorf("log truncated to %d bytes, want %d", info.Size(), offsets[2])
+	}
+}
+
+func TestFileStoreDropsTornHeader(t *testing.T) {
+	dir, data, _ := writeTestLog(t)
+
+	writeTestFile(t, filepath.Join(dir, logFileName), append(data, 0, 0, 1))
+
+	store, err := newTestFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	if got := len(store.Permissions().GetAll()); got != 3 {
+		t.Errorf("got %d permissions, want 3", got)
+	}
+}
+
+func TestFileStoreRejectsBadChecksumMidLog(t *testing.T) {
+	dir, data, offsets := writeTestLog(t)
+	path := filepath.Join(dir, logFileName)
+
+	data[offsets[1]+frameHeaderSize+1] ^= 0xff
+	writeTestFile(t, path, data)
+
+	if _, err := newTestFileStore(dir); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("got error %v, want ErrCorrupted", err)
+	}
+
+	assertLogUnchanged(t, path, data)
+}
+
+func TestFileStoreRejectsBadLengthMidLog(t *testing.T) {
+	dir, data, offsets := writeTestLog(t)
+	path := filepath.Join(dir, logFileName)
+
+	// The length now runs past the end of the log, like a torn frame.
+	binary.BigEndian.PutUint32(data[offsets[1]:], uint32(len(data)))
+	writeTestFile(t, path, data)
+
+	if _, err := newTestFileStore(dir); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("got error %v, want ErrCorrupted", err)
+	}
+
+	assertLogUnchanged(t, path, data)
+}
+
+func assertLogUnchanged(t *testing.T, path string, want []byte) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("log changed from %d to %d bytes", len(want), len(got))
+	}
+}
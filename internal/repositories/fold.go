@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldName returns the same key for every pair of names that strings.EqualFold
+// considers equal, by replacing each rune with the smallest one of its case
+// folding orbit.
+func foldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		folded := r
+		for next := unicode.SimpleFold(r); next != r; next = unicode.SimpleFold(next) {
+			folded = min(folded, next)
+		}
+
+		return folded
+	}, name)
+}
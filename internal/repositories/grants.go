@@ -0,0 +1,321 @@
+package repositories
+
+import (
+	"cmp"
+	"go-crud-gin/internal/models"
+	"slices"
+	"sync"
+)
+
+// GrantsRepository keeps the permissions granted directly to users and to
+// groups. Adding a grant that exists returns ErrAlreadyExists and removing
+// one that does not returns ErrNotFound.
+type GrantsRepository interface {
+	GetUserGrantsForUser(userID int) []models.UserPermission
+	GetUserGrantsForPermission(permissionID int) []models.UserPermission
+	HasUserGrant(userID, permissionID int) bool
+	AddUserGrant(grant models.UserPermission) error
+	RemoveUserGrant(grant models.UserPermission) error
+
+	// ApplyUserGrants removes and adds the grants at once, or changes nothing
+	// when any of them fails.
+	ApplyUserGrants(added, removed []models.UserPermission) error
+	DeleteUserGrants(userID int) error
+
+	GetGroupGrantsForGroup(groupID int) []models.GroupPermission
+	GetGroupGrantsForPermission(permissionID int) []models.GroupPermission
+	HasGroupGrant(groupID, permissionID int) bool
+	AddGroupGrant(grant models.GroupPermission) error
+	RemoveGroupGrant(grant models.GroupPermission) error
+	DeleteGroupGrants(groupID int) error
+
+	// DeletePermissionGrants removes the grants of the permission to users
+	// and groups.
+	DeletePermissionGrants(permissionID int) error
+}
+
+// grantIndex links the two sides of a grant, so the grants of either side
+// are found without reading the others. Each link keeps the sequence number
+// of its grant, and the links are read in the order they were granted.
+type grantIndex struct {
+	sequence *uint64
+	forward  map[int]map[int]uint64
+	backward map[int]map[int]uint64
+}
+
+func newGrantIndex() grantIndex {
+	return grantIndex{
+		sequence: new(uint64),
+		forward:  map[int]map[int]uint64{},
+		backward: map[int]map[int]uint64{},
+	}
+}
+
+func (index grantIndex) has(from, to int) bool {
+	_, ok := index.forward[from][to]
+	return ok
+}
+
+func (index grantIndex) add(from, to int) {
+	*index.sequence++
+	addLink(index.forward, from, to, *index.sequence)
+	addLink(index.backward, to, from, *index.sequence)
+}
+
+func (index grantIndex) remove(from, to int) {
+	removeLink(index.forward, from, to)
+	removeLink(index.backward, to, from)
+}
+
+// removeFrom removes every grant of from and removeTo every grant to to.
+func (index grantIndex) removeFrom(from int) {
+	for to := range index.forward[from] {
+		removeLink(index.backward, to, from)
+	}
+
+	delete(index.forward, from)
+}
+
+func (index grantIndex) removeTo(to int) {
+	for from := range index.backward[to] {
+		removeLink(index.forward, from, to)
+	}
+
+	delete(index.backward, to)
+}
+
+func addLink(links map[int]map[int]uint64, key, value int, sequence uint64) {
+	if links[key] == nil {
+		links[key] = map[int]uint64{}
+	}
+
+	links[key][value] = sequence
+}
+
+func removeLink(links map[int]map[int]uint64, key, value int) {
+	delete(links[key], value)
+	if len(links[key]) == 0 {
+		delete(links, key)
+	}
+}
+
+// all returns every link as a from and to pair, in the order they were
+// granted.
+func (index grantIndex) all() [][2]int {
+	links := [][2]int{}
+	for from, values := range index.forward {
+		for to := range values {
+			links = append(links, [2]int{from, to})
+		}
+	}
+
+	slices.SortFunc(links, func(a, b [2]int) int {
+		return cmp.Compare(index.forward[a[0]][a[1]], index.forward[b[0]][b[1]])
+	})
+
+	return links
+}
+
+// linked returns the values linked to key in the order they were granted.
+func linked(links map[int]map[int]uint64, key int) []int {
+	values := make([]int, 0, len(links[key]))
+	for value := range links[key] {
+		values = append(values, value)
+	}
+
+	slices.SortFunc(values, func(a, b int) int {
+		return cmp.Compare(links[key][a], links[key][b])
+	})
+
+	return values
+}
+
+// memoryGrantsRepository indexes the user grants by user and by permission,
+// and the group grants by group and by permission.
+type memoryGrantsRepository struct {
+	mutex       sync.RWMutex
+	userGrants  grantIndex
+	groupGrants grantIndex
+}
+
+func userGrants(userIDs []int, permissionIDs []int) []models.UserPermission {
+	grants := make([]models.UserPermission, len(userIDs)*len(permissionIDs))
+	i := 0
+	for _, userID := range userIDs {
+		for _, permissionID := range permissionIDs {
+			grants[i] = models.UserPermission{UserID: userID, PermissionID: permissionID}
+			i++
+		}
+	}
+
+	return grants
+}
+
+func groupGrants(groupIDs []int, permissionIDs []int) []models.GroupPermission {
+	grants := make([]models.GroupPermission, len(groupIDs)*len(permissionIDs))
+	i := 0
+	for _, groupID := range groupIDs {
+		for _, permissionID := range permissionIDs {
+			grants[i] = models.GroupPermission{GroupID: groupID, PermissionID: permissionID}
+			i++
+		}
+	}
+
+	return grants
+}
+
+func (repository *memoryGrantsRepository) GetUserGrantsForUser(userID int) []models.UserPermission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return userGrants([]int{userID}, linked(repository.userGrants.forward, userID))
+}
+
+func (repository *memoryGrantsRepository) GetUserGrantsForPermission(permissionID int) []models.UserPermission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return userGrants(linked(repository.userGrants.backward, permissionID), []int{permissionID})
+}
+
+func (repository *memoryGrantsRepository) HasUserGrant(userID, permissionID int) bool {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.userGrants.has(userID, permissionID)
+}
+
+func (repository *memoryGrantsRepository) AddUserGrant(grant models.UserPermission) error {
+	return repository.ApplyUserGrants([]models.UserPermission{grant}, nil)
+}
+
+func (repository *memoryGrantsRepository) RemoveUserGrant(grant models.UserPermission) error {
+	return repository.ApplyUserGrants(nil, []models.UserPermission{grant})
+}
+
+func (repository *memoryGrantsRepository) ApplyUserGrants(added, removed []models.UserPermission) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	// Everything is checked first, so a failure changes nothing.
+	held := map[models.UserPermission]bool{}
+	isHeld := func(grant models.UserPermission) bool {
+		if value, ok := held[grant]; ok {
+			return value
+		}
+
+		return repository.userGrants.has(grant.UserID, grant.PermissionID)
+	}
+
+	for _, grant := range removed {
+		if !isHeld(grant) {
+			return ErrNotFound
+		}
+
+		held[grant] = false
+	}
+
+	for _, grant := range added {
+		if isHeld(grant) {
+			return ErrAlreadyExists
+		}
+
+		held[grant] = true
+	}
+
+	for _, grant := range removed {
+		repository.userGrants.remove(grant.UserID, grant.PermissionID)
+	}
+
+	for _, grant := range added {
+		repository.userGrants.add(grant.UserID, grant.PermissionID)
+	}
+
+	return nil
+}
+
+func (repository *memoryGrantsRepository) DeleteUserGrants(userID int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.userGrants.removeFrom(userID)
+
+	return nil
+}
+
+func (repository *memoryGrantsRepository) GetGroupGrantsForGroup(groupID int) []models.GroupPermission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return groupGrants([]int{groupID}, linked(repository.groupGrants.forward, groupID))
+}
+
+func (repository *memoryGrantsRepository) GetGroupGrantsForPermission(permissionID int) []models.GroupPermission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return groupGrants(linked(repository.groupGrants.backward, permissionID), []int{permissionID})
+}
+
+func (repository *memoryGrantsRepository) HasGroupGrant(groupID, permissionID int) bool {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.groupGrants.has(groupID, permissionID)
+}
+
+func (repository *memoryGrantsRepository) AddGroupGrant(grant models.GroupPermission) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if repository.groupGrants.has(grant.GroupID, grant.PermissionID) {
+		return ErrAlreadyExists
+	}
+
+	repository.groupGrants.add(grant.GroupID, grant.PermissionID)
+
+	return nil
+}
+
+func (repository *memoryGrantsRepository) RemoveGroupGrant(grant models.GroupPermission) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if !repository.groupGrants.has(grant.GroupID, grant.PermissionID) {
+		return ErrNotFound
+	}
+
+	repository.groupGrants.remove(grant.GroupID, grant.PermissionID)
+
+	return nil
+}
+
+func (repository *memoryGrantsRepository) DeleteGroupGrants(groupID int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.groupGrants.removeFrom(groupID)
+
+	return nil
+}
+
+func (repository *memoryGrantsRepository) DeletePermissionGrants(permissionID int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.userGrants.removeTo(permissionID)
+	repository.groupGrants.removeTo(permissionID)
+
+	return nil
+}
+
+func NewMemoryGrantsRepository() GrantsRepository {
+	return newMemoryGrantsRepository()
+}
+
+func newMemoryGrantsRepository() *memoryGrantsRepository {
+	return &memoryGrantsRepository{
+		userGrants:  newGrantIndex(),
+		groupGrants: newGrantIndex(),
+	}
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"fmt"
+	"go-crud-gin/internal/models"
+	"testing"
+)
+
+const (
+	benchmarkPermissions   = 100
+	benchmarkGrantsPerUser = 10
+)
+
+// benchmarkGrants caches the repositories by number of users, each with
+// benchmarkGrantsPerUser grants, so the largest one holds 1M grants.
+var benchmarkGrants = map[int]GrantsRepository{}
+
+func newBenchmarkGrants(b *testing.B, users int) GrantsRepository {
+	b.Helper()
+
+	if grants, ok := benchmarkGrants[users]; ok {
+		return grants
+	}
+
+	grants := NewMemoryGrantsRepository()
+	for userID := 1; userID <= users; userID++ {
+		added := make([]models.UserPermission, benchmarkGrantsPerUser)
+		for i := range added {
+			added[i] = models.UserPermission{
+				UserID:       userID,
+				PermissionID: benchmarkPermission(userID, i),
+			}
+		}
+
+		if err := grants.ApplyUserGrants(added, nil); err != nil {
+			b.Fatalf("ApplyUserGrants %d: %v", userID, err)
+		}
+	}
+
+	benchmarkGrants[users] = grants
+
+	return grants
+}
+
+// benchmarkPermission spreads the grants of the users over every permission.
+func benchmarkPermission(userID, i int) int {
+	return (userID+i*7)%benchmarkPermissions + 1
+}
+
+func BenchmarkGrantsHasUserGrant(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("grants=%d", size*benchmarkGrantsPerUser), func(b *testing.B) {
+			grants := newBenchmarkGrants(b, size)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				userID := i%size + 1
+				if !grants.HasUserGrant(userID, benchmarkPermission(userID, i%benchmarkGrantsPerUser)) {
+					b.Fatalf("user %d misses a grant", userID)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGrantsGetUserGrantsForUser(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("grants=%d", size*benchmarkGrantsPerUser), func(b *testing.B) {
+			grants := newBenchmarkGrants(b, size)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if got := grants.GetUserGrantsForUser(i%size + 1); len(got) != benchmarkGrantsPerUser {
+					b.Fatalf("got %d grants, want %d", len(got), benchmarkGrantsPerUser)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"go-crud-gin/internal/models"
+	"slices"
+	"sync"
+)
+
+// GroupsRepository keeps the group names unique inside each organization,
+// compared case-insensitively, and the members of every group in the order
+// they were added. GetByName returns the groups called name in every
+// organization. Deleting a group removes its memberships, but the services
+// must delete its subgroups first.
+type GroupsRepository interface {
+	Create(group models.Group) (int, error)
+	GetByID(id int) *models.Group
+	GetByName(name string) []models.Group
+	GetAll() []models.Group
+	Delete(id int) error
+
+	GetMemberIDs(groupID int) []int
+	GetGroupIDsForUser(userID int) []int
+
+	// AddMembers adds every user to the group, or none when the group does
+	// not exist (ErrNotFound) or any of them is already a member
+	// (ErrAlreadyExists).
+	AddMembers(groupID int, userIDs []int) error
+	RemoveMember(groupID, userID int) error
+	DeleteMemberships(userID int) error
+}
+
+// memoryGroupsRepository indexes the groups by ID and case-folded name, and
+// links the groups and their members like the grants.
+type memoryGroupsRepository struct {
+	mutex  sync.RWMutex
+	lastID int
+
+	// ids keeps the creation order for GetAll.
+	ids    []int
+	groups map[int]models.Group
+	// idsByName holds, sorted, the IDs of the groups with each name in every
+	// organization.
+	idsByName map[string][]int
+
+	// members links the groups to their members.
+	members grantIndex
+}
+
+// cloneGroup copies the parent ID too, so callers cannot change the stored group.
+func cloneGroup(group models.Group) models.Group {
+	if group.ParentID != nil {
+		parentID := *group.ParentID
+		group.ParentID = &parentID
+	}
+
+	return group
+}
+
+func (repository *memoryGroupsRepository) get(id int) *models.Group {
+	group, ok := repository.groups[id]
+	if !ok {
+		return nil
+	}
+
+	group = cloneGroup(group)
+
+	return &group
+}
+
+func (repository *memoryGroupsRepository) index(group models.Group) {
+	repository.groups[group.ID] = cloneGroup(group)
+
+	name := foldName(group.Name)
+	ids := repository.idsByName[name]
+	if index, found := slices.BinarySearch(ids, group.ID); !found {
+		repository.idsByName[name] = slices.Insert(ids, index, group.ID)
+	}
+}
+
+func (repository *memoryGroupsRepository) unindex(group models.Group) {
+	delete(repository.groups, group.ID)
+
+	name := foldName(group.Name)
+	ids := repository.idsByName[name]
+	if index, found := slices.BinarySearch(ids, group.ID); found {
+		ids = slices.Delete(ids, index, index+1)
+	}
+
+	if len(ids) == 0 {
+		delete(repository.idsByName, name)
+	} else {
+		repository.idsByName[name] = ids
+	}
+}
+
+func (repository *memoryGroupsRepository) Create(group models.Group) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if group.ParentID != nil {
+		if _, ok := repository.groups[*group.ParentID]; !ok {
+			return 0, ErrNotFound
+		}
+	}
+
+	for _, id := range repository.idsByName[foldName(group.Name)] {
+		if repository.groups[id].OrganizationID == group.OrganizationID {
+			return 0, ErrAlreadyExists
+		}
+	}
+
+	repository.lastID++
+	group.ID = repository.lastID
+
+	repository.ids = append(repository.ids, group.ID)
+	repository.index(group)
+
+	return group.ID, nil
+}
+
+func (repository *memoryGroupsRepository) GetByID(id int) *models.Group {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(id)
+}
+
+func (repository *memoryGroupsRepository) GetByName(name string) []models.Group {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	ids := repository.idsByName[foldName(name)]
+
+	groups := make([]models.Group, len(ids))
+	for i, id := range ids {
+		groups[i] = cloneGroup(repository.groups[id])
+	}
+
+	return groups
+}
+
+func (repository *memoryGroupsRepository) GetAll() []models.Group {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	groups := make([]models.Group, len(repository.ids))
+	for i, id := range repository.ids {
+		groups[i] = cloneGroup(repository.groups[id])
+	}
+
+	return groups
+}
+
+func (repository *memoryGroupsRepository) Delete(id int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	group, ok := repository.groups[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	repository.unindex(group)
+	repository.members.removeFrom(id)
+
+	// IDs are sorted, since they only grow.
+	if index, found := slices.BinarySearch(repository.ids, id); found {
+		repository.ids = slices.Delete(repository.ids, index, index+1)
+	}
+
+	return nil
+}
+
+func (repository *memoryGroupsRepository) GetMemberIDs(groupID int) []int {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return linked(repository.members.forward, groupID)
+}
+
+func (repository *memoryGroupsRepository) GetGroupIDsForUser(userID int) []int {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return linked(repository.members.backward, userID)
+}
+
+func (repository *memoryGroupsRepository) AddMembers(groupID int, userIDs []int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.groups[groupID]; !ok {
+		return ErrNotFound
+	}
+
+	for i, userID := range userIDs {
+		if repository.members.has(groupID, userID) || slices.Contains(userIDs[:i], userID) {
+			return ErrAlreadyExists
+		}
+	}
+
+	for _, userID := range userIDs {
+		repository.members.add(groupID, userID)
+	}
+
+	return nil
+}
+
+func (repository *memoryGroupsRepository) RemoveMember(groupID, userID int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if !repository.members.has(groupID, userID) {
+		return ErrNotFound
+	}
+
+	repository.members.remove(groupID, userID)
+
+	return nil
+}
+
+func (repository *memoryGroupsRepository) DeleteMemberships(userID int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.members.removeTo(userID)
+
+	return nil
+}
+
+func NewMemoryGroupsRepository() GroupsRepository {
+	return newMemoryGroupsRepository()
+}
+
+func newMemoryGroupsRepository() *memoryGroupsRepository {
+	return &memoryGroupsRepository{
+		ids:       []int{},
+		groups:    map[int]models.Group{},
+		idsByName: map[string][]int{},
+		members:   newGrantIndex(),
+	}
+}
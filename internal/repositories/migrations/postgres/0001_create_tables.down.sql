@@ -0,0 +1,4 @@
+DROP TABLE user_grants;
+DROP TABLE permissions;
+DROP TABLE users;
+DROP TABLE sequences;
@@ -0,0 +1,54 @@
+-- The store assigns the IDs from these sequences, so they are never reused
+-- whatever the database does with the IDs of deleted rows.
+CREATE TABLE sequences (
+	name VARCHAR(50) PRIMARY KEY,
+	last_id INTEGER NOT NULL
+);
+
+INSERT INTO sequences (name, last_id) VALUES ('users', 0), ('permissions', 0), ('user_grants', 0);
+
+-- username_key, email_key and name_key hold the case-folded names, so the
+-- unique constraints ignore case like the services do. email_key is NULL for
+-- the users without email.
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	public_id VARCHAR(36) NOT NULL UNIQUE,
+	organization_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	username_key TEXT NOT NULL UNIQUE,
+	display_name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	email_key TEXT UNIQUE,
+	locale TEXT NOT NULL,
+	attributes TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	suspension_reason TEXT NOT NULL,
+	suspended_until TIMESTAMPTZ,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE permissions (
+	id INTEGER PRIMARY KEY,
+	public_id VARCHAR(36) NOT NULL UNIQUE,
+	organization_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	description TEXT NOT NULL,
+	deletable BOOLEAN NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	UNIQUE (organization_id, name_key)
+);
+
+CREATE INDEX permissions_name_key ON permissions (name_key);
+
+-- id keeps the order in which the permissions were granted.
+CREATE TABLE user_grants (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+	UNIQUE (user_id, permission_id)
+);
+
+CREATE INDEX user_grants_permission_id ON user_grants (permission_id);
@@ -0,0 +1,18 @@
+DROP TABLE access_requests;
+DROP TABLE group_grants;
+DROP TABLE group_members;
+DROP TABLE user_groups;
+DROP TABLE user_attributes;
+
+DROP INDEX permissions_organization_name;
+ALTER TABLE permissions DROP CONSTRAINT permissions_organization_id_fkey;
+UPDATE permissions SET organization_id = 0 WHERE organization_id IS NULL;
+ALTER TABLE permissions ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE permissions ADD CONSTRAINT permissions_organization_id_name_key_key UNIQUE (organization_id, name_key);
+
+DROP INDEX users_organization_id;
+ALTER TABLE users DROP CONSTRAINT users_organization_id_fkey;
+
+DROP TABLE organizations;
+
+DELETE FROM sequences WHERE name IN ('organizations', 'user_attributes', 'user_groups', 'group_members', 'group_grants', 'access_requests');
@@ -0,0 +1,105 @@
+INSERT INTO sequences (name, last_id) VALUES
+	('organizations', 0),
+	('user_attributes', 0),
+	('user_groups', 0),
+	('group_members', 0),
+	('group_grants', 0),
+	('access_requests', 0);
+
+-- The *_key columns hold the case-folded names, so the unique constraints
+-- ignore case like the services do.
+CREATE TABLE organizations (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL UNIQUE
+);
+
+-- The organizations were not stored before, so the default one and the ones
+-- of the existing users and permissions are created with a generated name.
+INSERT INTO organizations (id, name, name_key) VALUES (1, 'default', 'default');
+
+INSERT INTO organizations (id, name, name_key)
+SELECT organization_id, 'organization_' || organization_id, 'organization_' || organization_id
+FROM (
+	SELECT organization_id FROM users
+	UNION
+	SELECT organization_id FROM permissions WHERE organization_id <> 0
+) AS organization_ids
+WHERE organization_id <> 1;
+
+UPDATE sequences SET last_id = (SELECT MAX(id) FROM organizations) WHERE name = 'organizations';
+
+ALTER TABLE users ADD CONSTRAINT users_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations (id);
+CREATE INDEX users_organization_id ON users (organization_id);
+
+-- organization_id is NULL for the attributes and permissions shared by every
+-- organization, so the unique indexes compare it as 0.
+ALTER TABLE permissions ALTER COLUMN organization_id DROP NOT NULL;
+UPDATE permissions SET organization_id = NULL WHERE organization_id = 0;
+ALTER TABLE permissions DROP CONSTRAINT permissions_organization_id_name_key_key;
+ALTER TABLE permissions ADD CONSTRAINT permissions_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations (id);
+CREATE UNIQUE INDEX permissions_organization_name ON permissions (COALESCE(organization_id, 0), name_key);
+
+CREATE TABLE user_attributes (
+	id INTEGER PRIMARY KEY,
+	organization_id INTEGER REFERENCES organizations (id),
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	type VARCHAR(20) NOT NULL,
+	required BOOLEAN NOT NULL,
+	allowed_values TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX user_attributes_organization_name ON user_attributes (COALESCE(organization_id, 0), name_key);
+
+-- Groups with subgroups cannot be deleted, so parent_id has no ON DELETE.
+CREATE TABLE user_groups (
+	id INTEGER PRIMARY KEY,
+	organization_id INTEGER NOT NULL REFERENCES organizations (id),
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	description TEXT NOT NULL,
+	parent_id INTEGER REFERENCES user_groups (id),
+	UNIQUE (organization_id, name_key)
+);
+
+CREATE INDEX user_groups_name_key ON user_groups (name_key);
+CREATE INDEX user_groups_parent_id ON user_groups (parent_id);
+
+-- The id of the memberships and grants keeps the order in which they were
+-- added.
+CREATE TABLE group_members (
+	id INTEGER PRIMARY KEY,
+	group_id INTEGER NOT NULL REFERENCES user_groups (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	UNIQUE (group_id, user_id)
+);
+
+CREATE INDEX group_members_user_id ON group_members (user_id);
+
+CREATE TABLE group_grants (
+	id INTEGER PRIMARY KEY,
+	group_id INTEGER NOT NULL REFERENCES user_groups (id) ON DELETE CASCADE,
+	permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+	UNIQUE (group_id, permission_id)
+);
+
+CREATE INDEX group_grants_permission_id ON group_grants (permission_id);
+
+-- The requests are kept after their user or permission is deleted, as a
+-- record of what was asked and who approved it.
+CREATE TABLE access_requests (
+	id INTEGER PRIMARY KEY,
+	organization_id INTEGER NOT NULL REFERENCES organizations (id),
+	user_id INTEGER NOT NULL,
+	permission_id INTEGER NOT NULL,
+	justification TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	required_approvals INTEGER NOT NULL,
+	approved_by TEXT NOT NULL,
+	denied_by INTEGER,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	resolved_at TIMESTAMPTZ,
+	resolution_reason TEXT NOT NULL
+);
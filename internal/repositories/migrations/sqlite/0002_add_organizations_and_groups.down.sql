@@ -0,0 +1,70 @@
+DROP TABLE access_requests;
+DROP TABLE group_grants;
+DROP TABLE group_members;
+DROP TABLE user_groups;
+DROP TABLE user_attributes;
+
+-- users, permissions and user_grants are copied back into tables without the
+-- references to the organizations, like in 0002_add_organizations_and_groups.up.sql.
+CREATE TABLE users_old (
+	id INTEGER PRIMARY KEY,
+	public_id VARCHAR(36) NOT NULL UNIQUE,
+	organization_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	username_key TEXT NOT NULL UNIQUE,
+	display_name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	email_key TEXT UNIQUE,
+	locale TEXT NOT NULL,
+	attributes TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	suspension_reason TEXT NOT NULL,
+	suspended_until TIMESTAMP,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	deleted_at TIMESTAMP
+);
+
+INSERT INTO users_old SELECT
+	id, public_id, organization_id, username, username_key, display_name, email, email_key, locale, attributes,
+	status, suspension_reason, suspended_until, password_hash, created_at, deleted_at
+FROM users;
+
+CREATE TABLE permissions_old (
+	id INTEGER PRIMARY KEY,
+	public_id VARCHAR(36) NOT NULL UNIQUE,
+	organization_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	description TEXT NOT NULL,
+	deletable BOOLEAN NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	UNIQUE (organization_id, name_key)
+);
+
+INSERT INTO permissions_old SELECT
+	id, public_id, COALESCE(organization_id, 0), name, name_key, description, deletable, created_at
+FROM permissions;
+
+CREATE TABLE user_grants_old (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users_old (id) ON DELETE CASCADE,
+	permission_id INTEGER NOT NULL REFERENCES permissions_old (id) ON DELETE CASCADE,
+	UNIQUE (user_id, permission_id)
+);
+
+INSERT INTO user_grants_old SELECT id, user_id, permission_id FROM user_grants;
+
+DROP TABLE user_grants;
+DROP TABLE permissions;
+DROP TABLE users;
+DROP TABLE organizations;
+
+ALTER TABLE users_old RENAME TO users;
+ALTER TABLE permissions_old RENAME TO permissions;
+ALTER TABLE user_grants_old RENAME TO user_grants;
+
+CREATE INDEX permissions_name_key ON permissions (name_key);
+CREATE INDEX user_grants_permission_id ON user_grants (permission_id);
+
+DELETE FROM sequences WHERE name IN ('organizations', 'user_attributes', 'user_groups', 'group_members', 'group_grants', 'access_requests');
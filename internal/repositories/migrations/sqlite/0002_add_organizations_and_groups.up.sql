@@ -0,0 +1,161 @@
+INSERT INTO sequences (name, last_id) VALUES
+	('organizations', 0),
+	('user_attributes', 0),
+	('user_groups', 0),
+	('group_members', 0),
+	('group_grants', 0),
+	('access_requests', 0);
+
+-- The *_key columns hold the case-folded names, so the unique constraints
+-- ignore case like the services do.
+CREATE TABLE organizations (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL UNIQUE
+);
+
+-- The organizations were not stored before, so the default one and the ones
+-- of the existing users and permissions are created with a generated name.
+INSERT INTO organizations (id, name, name_key) VALUES (1, 'default', 'default');
+
+INSERT INTO organizations (id, name, name_key)
+SELECT organization_id, 'organization_' || organization_id, 'organization_' || organization_id
+FROM (
+	SELECT organization_id FROM users
+	UNION
+	SELECT organization_id FROM permissions WHERE organization_id <> 0
+) AS organization_ids
+WHERE organization_id <> 1;
+
+UPDATE sequences SET last_id = (SELECT MAX(id) FROM organizations) WHERE name = 'organizations';
+
+-- SQLite cannot add foreign keys to a table, so users, permissions and
+-- user_grants are copied into new tables. The old ones are dropped after
+-- user_grants, which would otherwise lose its rows to the cascades, and
+-- renaming the new ones updates the references to them.
+CREATE TABLE users_new (
+	id INTEGER PRIMARY KEY,
+	public_id VARCHAR(36) NOT NULL UNIQUE,
+	organization_id INTEGER NOT NULL REFERENCES organizations (id),
+	username TEXT NOT NULL,
+	username_key TEXT NOT NULL UNIQUE,
+	display_name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	email_key TEXT UNIQUE,
+	locale TEXT NOT NULL,
+	attributes TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	suspension_reason TEXT NOT NULL,
+	suspended_until TIMESTAMP,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	deleted_at TIMESTAMP
+);
+
+INSERT INTO users_new SELECT
+	id, public_id, organization_id, username, username_key, display_name, email, email_key, locale, attributes,
+	status, suspension_reason, suspended_until, password_hash, created_at, deleted_at
+FROM users;
+
+-- organization_id is NULL for the attributes and permissions shared by every
+-- organization, so the unique indexes compare it as 0.
+CREATE TABLE permissions_new (
+	id INTEGER PRIMARY KEY,
+	public_id VARCHAR(36) NOT NULL UNIQUE,
+	organization_id INTEGER REFERENCES organizations (id),
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	description TEXT NOT NULL,
+	deletable BOOLEAN NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO permissions_new SELECT
+	id, public_id, NULLIF(organization_id, 0), name, name_key, description, deletable, created_at
+FROM permissions;
+
+CREATE TABLE user_grants_new (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users_new (id) ON DELETE CASCADE,
+	permission_id INTEGER NOT NULL REFERENCES permissions_new (id) ON DELETE CASCADE,
+	UNIQUE (user_id, permission_id)
+);
+
+INSERT INTO user_grants_new SELECT id, user_id, permission_id FROM user_grants;
+
+DROP TABLE user_grants;
+DROP TABLE permissions;
+DROP TABLE users;
+
+ALTER TABLE users_new RENAME TO users;
+ALTER TABLE permissions_new RENAME TO permissions;
+ALTER TABLE user_grants_new RENAME TO user_grants;
+
+CREATE INDEX users_organization_id ON users (organization_id);
+CREATE UNIQUE INDEX permissions_organization_name ON permissions (COALESCE(organization_id, 0), name_key);
+CREATE INDEX permissions_name_key ON permissions (name_key);
+CREATE INDEX user_grants_permission_id ON user_grants (permission_id);
+
+CREATE TABLE user_attributes (
+	id INTEGER PRIMARY KEY,
+	organization_id INTEGER REFERENCES organizations (id),
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	type VARCHAR(20) NOT NULL,
+	required BOOLEAN NOT NULL,
+	allowed_values TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX user_attributes_organization_name ON user_attributes (COALESCE(organization_id, 0), name_key);
+
+-- Groups with subgroups cannot be deleted, so parent_id has no ON DELETE.
+CREATE TABLE user_groups (
+	id INTEGER PRIMARY KEY,
+	organization_id INTEGER NOT NULL REFERENCES organizations (id),
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	description TEXT NOT NULL,
+	parent_id INTEGER REFERENCES user_groups (id),
+	UNIQUE (organization_id, name_key)
+);
+
+CREATE INDEX user_groups_name_key ON user_groups (name_key);
+CREATE INDEX user_groups_parent_id ON user_groups (parent_id);
+
+-- The id of the memberships and grants keeps the order in which they were
+-- added.
+CREATE TABLE group_members (
+	id INTEGER PRIMARY KEY,
+	group_id INTEGER NOT NULL REFERENCES user_groups (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	UNIQUE (group_id, user_id)
+);
+
+CREATE INDEX group_members_user_id ON group_members (user_id);
+
+CREATE TABLE group_grants (
+	id INTEGER PRIMARY KEY,
+	group_id INTEGER NOT NULL REFERENCES user_groups (id) ON DELETE CASCADE,
+	permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+	UNIQUE (group_id, permission_id)
+);
+
+CREATE INDEX group_grants_permission_id ON group_grants (permission_id);
+
+-- The requests are kept after their user or permission is deleted, as a
+-- record of what was asked and who approved it.
+CREATE TABLE access_requests (
+	id INTEGER PRIMARY KEY,
+	organization_id INTEGER NOT NULL REFERENCES organizations (id),
+	user_id INTEGER NOT NULL,
+	permission_id INTEGER NOT NULL,
+	justification TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	required_approvals INTEGER NOT NULL,
+	approved_by TEXT NOT NULL,
+	denied_by INTEGER,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	resolved_at TIMESTAMP,
+	resolution_reason TEXT NOT NULL
+);
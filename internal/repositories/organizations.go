@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"go-crud-gin/internal/models"
+	"sync"
+)
+
+// OrganizationsRepository keeps the organization names unique, compared
+// case-insensitively.
+type OrganizationsRepository interface {
+	Create(organization models.Organization) (int, error)
+	GetByID(id int) *models.Organization
+	GetByName(name string) *models.Organization
+	GetAll() []models.Organization
+}
+
+// memoryOrganizationsRepository indexes the organizations by ID and
+// case-folded name.
+type memoryOrganizationsRepository struct {
+	mutex  sync.RWMutex
+	lastID int
+
+	// ids keeps the creation order for GetAll.
+	ids           []int
+	organizations map[int]models.Organization
+	idsByName     map[string]int
+}
+
+func (repository *memoryOrganizationsRepository) get(id int) *models.Organization {
+	organization, ok := repository.organizations[id]
+	if !ok {
+		return nil
+	}
+
+	return &organization
+}
+
+func (repository *memoryOrganizationsRepository) index(organization models.Organization) {
+	repository.organizations[organization.ID] = organization
+	repository.idsByName[foldName(organization.Name)] = organization.ID
+}
+
+func (repository *memoryOrganizationsRepository) Create(organization models.Organization) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.idsByName[foldName(organization.Name)]; ok {
+		return 0, ErrAlreadyExists
+	}
+
+	repository.lastID++
+	organization.ID = repository.lastID
+
+	repository.ids = append(repository.ids, organization.ID)
+	repository.index(organization)
+
+	return organization.ID, nil
+}
+
+func (repository *memoryOrganizationsRepository) GetByID(id int) *models.Organization {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(id)
+}
+
+func (repository *memoryOrganizationsRepository) GetByName(name string) *models.Organization {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(repository.idsByName[foldName(name)])
+}
+
+func (repository *memoryOrganizationsRepository) GetAll() []models.Organization {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	organizations := make([]models.Organization, len(repository.ids))
+	for i, id := range repository.ids {
+		organizations[i] = repository.organizations[id]
+	}
+
+	return organizations
+}
+
+func NewMemoryOrganizationsRepository() OrganizationsRepository {
+	return newMemoryOrganizationsRepository()
+}
+
+func newMemoryOrganizationsRepository() *memoryOrganizationsRepository {
+	return &memoryOrganizationsRepository{
+		ids:           []int{},
+		organizations: map[int]models.Organization{},
+		idsByName:     map[string]int{},
+	}
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"go-crud-gin/internal/models"
+	"slices"
+	"sync"
+)
+
+// PermissionsRepository only keeps names unique inside each organization;
+// the services check the clashes with the global permissions. GetByName
+// returns the permissions called name in every organization.
+type PermissionsRepository interface {
+	Create(permission models.Permission) (int, error)
+	GetByID(id int) *models.Permission
+	GetByPublicID(publicID string) *models.Permission
+	GetByName(name string) []models.Permission
+	GetAll() []models.Permission
+	Update(permission models.Permission) error
+	Delete(id int) error
+}
+
+// memoryPermissionsRepository indexes the permissions by ID, public ID and
+// case-folded name.
+type memoryPermissionsRepository struct {
+	mutex  sync.RWMutex
+	lastID int
+
+	// ids keeps the creation order for GetAll.
+	ids         []int
+	permissions map[int]models.Permission
+
+	idsByPublicID map[string]int
+	// idsByName holds, sorted, the IDs of the permissions with each name in
+	// every organization.
+	idsByName map[string][]int
+}
+
+func (repository *memoryPermissionsRepository) get(id int) *models.Permission {
+	permission, ok := repository.permissions[id]
+	if !ok {
+		return nil
+	}
+
+	return &permission
+}
+
+func (repository *memoryPermissionsRepository) index(permission models.Permission) {
+	repository.permissions[permission.ID] = permission
+	repository.idsByPublicID[permission.PublicID] = permission.ID
+
+	name := foldName(permission.Name)
+	ids := repository.idsByName[name]
+	if index, found := slices.BinarySearch(ids, permission.ID); !found {
+		repository.idsByName[name] = slices.Insert(ids, index, permission.ID)
+	}
+}
+
+func (repository *memoryPermissionsRepository) unindex(permission models.Permission) {
+	delete(repository.permissions, permission.ID)
+	delete(repository.idsByPublicID, permission.PublicID)
+
+	name := foldName(permission.Name)
+	ids := repository.idsByName[name]
+	if index, found := slices.BinarySearch(ids, permission.ID); found {
+		ids = slices.Delete(ids, index, index+1)
+	}
+
+	if len(ids) == 0 {
+		delete(repository.idsByName, name)
+	} else {
+		repository.idsByName[name] = ids
+	}
+}
+
+// clashes tells whether another permission of the organization has the name.
+func (repository *memoryPermissionsRepository) clashes(permission models.Permission) bool {
+	for _, id := range repository.idsByName[foldName(permission.Name)] {
+		if id != permission.ID && repository.permissions[id].OrganizationID == permission.OrganizationID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (repository *memoryPermissionsRepository) Create(permission models.Permission) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	permission.ID = 0
+	if repository.clashes(permission) {
+		return 0, ErrAlreadyExists
+	}
+
+	repository.lastID++
+	permission.ID = repository.lastID
+
+	repository.ids = append(repository.ids, permission.ID)
+	repository.index(permission)
+
+	return permission.ID, nil
+}
+
+func (repository *memoryPermissionsRepository) GetByID(id int) *models.Permission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(id)
+}
+
+func (repository *memoryPermissionsRepository) GetByPublicID(publicID string) *models.Permission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(repository.idsByPublicID[publicID])
+}
+
+func (repository *memoryPermissionsRepository) GetByName(name string) []models.Permission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	ids := repository.idsByName[foldName(name)]
+
+	permissions := make([]models.Permission, len(ids))
+	for i, id := range ids {
+		permissions[i] = repository.permissions[id]
+	}
+
+	return permissions
+}
+
+func (repository *memoryPermissionsRepository) GetAll() []models.Permission {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	permissions := make([]models.Permission, len(repository.ids))
+	for i, id := range repository.ids {
+		permissions[i] = repository.permissions[id]
+	}
+
+	return permissions
+}
+
+func (repository *memoryPermissionsRepository) Update(permission models.Permission) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	current, ok := repository.permissions[permission.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if repository.clashes(permission) {
+		return ErrAlreadyExists
+	}
+
+	repository.unindex(current)
+	repository.index(permission)
+
+	return nil
+}
+
+func (repository *memoryPermissionsRepository) Delete(id int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	permission, ok := repository.permissions[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	repository.unindex(permission)
+
+	// IDs are sorted, since they only grow.
+	if index, found := slices.BinarySearch(repository.ids, id); found {
+		repository.ids = slices.Delete(repository.ids, index, index+1)
+	}
+
+	return nil
+}
+
+func NewMemoryPermissionsRepository() PermissionsRepository {
+	return newMemoryPermissionsRepository()
+}
+
+func newMemoryPermissionsRepository() *memoryPermissionsRepository {
+	return &memoryPermissionsRepository{
+		ids:           []int{},
+		permissions:   map[int]models.Permission{},
+		idsByPublicID: map[string]int{},
+		idsByName:     map[string][]int{},
+	}
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"fmt"
+	"go-crud-gin/internal/models"
+	"testing"
+)
+
+func BenchmarkPermissionsGetByName(b *testing.B) {
+	for _, size := range []int{100, 1_000, 10_000} {
+		b.Run(fmt.Sprintf("permissions=%d", size), func(b *testing.B) {
+			permissions := NewMemoryPermissionsRepository()
+			names := make([]string, size)
+			for i := range names {
+				names[i] = fmt.Sprintf("permission_%d", i)
+
+				_, err := permissions.Create(models.Permission{
+					PublicID: fmt.Sprintf("public-%d", i),
+					Name:     names[i],
+				})
+				if err != nil {
+					b.Fatalf("Create %s: %v", names[i], err)
+				}
+			}
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if got := permissions.GetByName(names[i%size]); len(got) != 1 {
+					b.Fatalf("got %d permissions named %s, want 1", len(got), names[i%size])
+				}
+			}
+		})
+	}
+}
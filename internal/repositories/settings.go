@@ -0,0 +1,43 @@
+package repositories
+
+import "sync"
+
+// SettingsRepository keeps named values of the application itself, such as
+// whether the seed was applied, next to the data they describe.
+type SettingsRepository interface {
+	Get(name string) (string, bool)
+	Set(name, value string) error
+}
+
+type memorySettingsRepository struct {
+	mutex    sync.RWMutex
+	settings map[string]string
+}
+
+func (repository *memorySettingsRepository) Get(name string) (string, bool) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	value, ok := repository.settings[name]
+
+	return value, ok
+}
+
+func (repository *memorySettingsRepository) Set(name, value string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.settings[name] = value
+
+	return nil
+}
+
+func NewMemorySettingsRepository() SettingsRepository {
+	return newMemorySettingsRepository()
+}
+
+func newMemorySettingsRepository() *memorySettingsRepository {
+	return &memorySettingsRepository{
+		settings: map[string]string{},
+	}
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The migrations of each dialect live in migrations/<dialect> as pairs of
+// <version>_<name>.up.sql and <version>_<name>.down.sql files. Versions
+// only grow and applied migrations are never edited.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+func loadMigrations(dialect Dialect) ([]migration, error) {
+	dir := path.Join("migrations", string(dialect))
+
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	migrations := map[int]*migration{}
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		script, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if migrations[version] == nil {
+			migrations[version] = &migration{Version: version, Name: name}
+		}
+
+		if direction == "up" {
+			migrations[version].Up = string(script)
+		} else {
+			migrations[version].Down = string(script)
+		}
+	}
+
+	sorted := []migration{}
+	for _, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %s needs an up and a down file", migration.Name)
+		}
+
+		sorted = append(sorted, *migration)
+	}
+
+	slices.SortFunc(sorted, func(a, b migration) int {
+		return a.Version - b.Version
+	})
+
+	return sorted, nil
+}
+
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMP NOT NULL)")
+	return err
+}
+
+// SchemaVersion returns the version of the last migration applied to db, or
+// 0 when there is none.
+func SchemaVersion(db *sql.DB) (int, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return int(version.Int64), nil
+}
+
+// LatestSchemaVersion returns the version of the last migration of dialect.
+func LatestSchemaVersion(dialect Dialect) (int, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil || len(migrations) == 0 {
+		return 0, err
+	}
+
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// runMigration runs script and record, which updates schema_migrations, in
+// one transaction, so a failed migration leaves nothing half done.
+func runMigration(db *sql.DB, script string, record func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MigrateUp applies the pending migrations of dialect to db, oldest first,
+// and returns how many it applied.
+func MigrateUp(db *sql.DB, dialect Dialect) (int, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		err := runMigration(db, migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(dialect.rebind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"), migration.Version, time.Now())
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+
+		applied++
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the last steps migrations applied to db, newest first,
+// and returns how many it reverted.
+func MigrateDown(db *sql.DB, dialect Dialect, steps int) (int, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for i := len(migrations) - 1; i >= 0 && reverted < steps; i-- {
+		migration := migrations[i]
+		if migration.Version > current {
+			continue
+		}
+
+		err := runMigration(db, migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(dialect.rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+
+		reverted++
+	}
+
+	return reverted, nil
+}
@@ -0,0 +1,799 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-crud-gin/internal/models"
+	"time"
+)
+
+// nullableOrganization returns organizationID, or NULL for the global
+// organization 0, which has no row.
+func nullableOrganization(organizationID int) any {
+	if organizationID == 0 {
+		return nil
+	}
+
+	return organizationID
+}
+
+// nullableInt returns the value of value, or NULL when it is nil.
+func nullableInt(value *int) any {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
+
+func intPointer(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+
+	id := int(value.Int64)
+
+	return &id
+}
+
+func scanID(row row) (int, error) {
+	var id int
+	err := row.Scan(&id)
+
+	return id, err
+}
+
+const organizationColumns = "id, name"
+
+func scanOrganization(row row) (models.Organization, error) {
+	var organization models.Organization
+	err := row.Scan(&organization.ID, &organization.Name)
+
+	return organization, err
+}
+
+type sqlOrganizationsRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlOrganizationsRepository) Create(organization models.Organization) (int, error) {
+	err := repository.store.transaction(func(tx *sql.Tx) error {
+		id, err := repository.store.nextID(tx, "organizations")
+		if err != nil {
+			return err
+		}
+
+		organization.ID = id
+
+		_, err = repository.store.exec(tx, "INSERT INTO organizations ("+organizationColumns+", name_key) VALUES (?, ?, ?)", organization.ID, organization.Name, foldName(organization.Name))
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return organization.ID, nil
+}
+
+func (repository *sqlOrganizationsRepository) GetByID(id int) *models.Organization {
+	return mustRead(queryOne(repository.store, "SELECT "+organizationColumns+" FROM organizations WHERE id = ?", scanOrganization, id))
+}
+
+func (repository *sqlOrganizationsRepository) GetByName(name string) *models.Organization {
+	return mustRead(queryOne(repository.store, "SELECT "+organizationColumns+" FROM organizations WHERE name_key = ?", scanOrganization, foldName(name)))
+}
+
+func (repository *sqlOrganizationsRepository) GetAll() []models.Organization {
+	return mustRead(queryAll(repository.store, "SELECT "+organizationColumns+" FROM organizations ORDER BY id", scanOrganization))
+}
+
+const userColumns = "id, public_id, organization_id, username, display_name, email, locale, attributes, status, suspension_reason, suspended_until, password_hash, created_at, deleted_at"
+
+// nullableTime returns the value of value, or NULL when it is nil.
+func nullableTime(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
+
+func timePointer(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Time
+}
+
+func scanUser(row row) (models.User, error) {
+	var user models.User
+	var attributes string
+	var suspendedUntil, deletedAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID,
+		&user.PublicID,
+		&user.OrganizationID,
+		&user.Username,
+		&user.DisplayName,
+		&user.Email,
+		&user.Locale,
+		&attributes,
+		&user.Status,
+		&user.SuspensionReason,
+		&suspendedUntil,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return user, err
+	}
+
+	if err := json.Unmarshal([]byte(attributes), &user.Attributes); err != nil {
+		return user, err
+	}
+
+	user.SuspendedUntil = timePointer(suspendedUntil)
+	user.DeletedAt = timePointer(deletedAt)
+
+	return user, nil
+}
+
+// userValues returns the values of userColumns, followed by the case-folded
+// username and email.
+func userValues(user models.User) ([]any, error) {
+	attributes, err := json.Marshal(user.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var emailKey any
+	if user.Email != "" {
+		emailKey = foldName(user.Email)
+	}
+
+	return []any{
+		user.ID,
+		user.PublicID,
+		user.OrganizationID,
+		user.Username,
+		user.DisplayName,
+		user.Email,
+		user.Locale,
+		string(attributes),
+		string(user.Status),
+		user.SuspensionReason,
+		nullableTime(user.SuspendedUntil),
+		user.PasswordHash,
+		user.CreatedAt,
+		nullableTime(user.DeletedAt),
+		foldName(user.Username),
+		emailKey,
+	}, nil
+}
+
+type sqlUsersRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlUsersRepository) Create(user models.User) (int, error) {
+	err := repository.store.transaction(func(tx *sql.Tx) error {
+		id, err := repository.store.nextID(tx, "users")
+		if err != nil {
+			return err
+		}
+
+		user.ID = id
+
+		values, err := userValues(user)
+		if err != nil {
+			return err
+		}
+
+		_, err = repository.store.exec(tx, "INSERT INTO users ("+userColumns+", username_key, email_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", values...)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
+
+func (repository *sqlUsersRepository) GetByID(id int) *models.User {
+	return mustRead(queryOne(repository.store, "SELECT "+userColumns+" FROM users WHERE id = ?", scanUser, id))
+}
+
+func (repository *sqlUsersRepository) GetByPublicID(publicID string) *models.User {
+	return mustRead(queryOne(repository.store, "SELECT "+userColumns+" FROM users WHERE public_id = ?", scanUser, publicID))
+}
+
+func (repository *sqlUsersRepository) GetByUsername(username string) *models.User {
+	return mustRead(queryOne(repository.store, "SELECT "+userColumns+" FROM users WHERE username_key = ?", scanUser, foldName(username)))
+}
+
+func (repository *sqlUsersRepository) GetByEmail(email string) *models.User {
+	if email == "" {
+		return nil
+	}
+
+	return mustRead(queryOne(repository.store, "SELECT "+userColumns+" FROM users WHERE email_key = ?", scanUser, foldName(email)))
+}
+
+func (repository *sqlUsersRepository) GetAll() []models.User {
+	return mustRead(queryAll(repository.store, "SELECT "+userColumns+" FROM users ORDER BY id", scanUser))
+}
+
+func (repository *sqlUsersRepository) Update(user models.User) error {
+	values, err := userValues(user)
+	if err != nil {
+		return err
+	}
+
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(
+			tx,
+			`UPDATE users SET public_id = ?, organization_id = ?, username = ?, display_name = ?, email = ?, locale = ?, attributes = ?,
+				status = ?, suspension_reason = ?, suspended_until = ?, password_hash = ?, created_at = ?, deleted_at = ?,
+				username_key = ?, email_key = ?
+			WHERE id = ?`,
+			append(values[1:], user.ID)...,
+		)
+	})
+}
+
+// Delete also removes the grants and memberships of the user, through the
+// foreign keys.
+func (repository *sqlUsersRepository) Delete(id int) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(tx, "DELETE FROM users WHERE id = ?", id)
+	})
+}
+
+const userAttributeColumns = "organization_id, name, type, required, allowed_values"
+
+func scanUserAttribute(row row) (models.UserAttributeDefinition, error) {
+	var definition models.UserAttributeDefinition
+	var organizationID sql.NullInt64
+	var allowedValues string
+
+	err := row.Scan(&organizationID, &definition.Name, &definition.Type, &definition.Required, &allowedValues)
+	if err != nil {
+		return definition, err
+	}
+
+	definition.OrganizationID = int(organizationID.Int64)
+
+	return definition, json.Unmarshal([]byte(allowedValues), &definition.AllowedValues)
+}
+
+// sqlUserAttributesRepository keeps the definitions in the order of their
+// IDs, which Save keeps when it replaces one.
+type sqlUserAttributesRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlUserAttributesRepository) Save(definition models.UserAttributeDefinition) error {
+	allowedValues, err := json.Marshal(definition.AllowedValues)
+	if err != nil {
+		return err
+	}
+
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		err := repository.store.execOne(
+			tx,
+			"UPDATE user_attributes SET name = ?, type = ?, required = ?, allowed_values = ? WHERE COALESCE(organization_id, 0) = ? AND name_key = ?",
+			definition.Name, string(definition.Type), definition.Required, string(allowedValues), definition.OrganizationID, foldName(definition.Name),
+		)
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		id, err := repository.store.nextID(tx, "user_attributes")
+		if err != nil {
+			return err
+		}
+
+		_, err = repository.store.exec(
+			tx,
+			"INSERT INTO user_attributes (id, "+userAttributeColumns+", name_key) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			id, nullableOrganization(definition.OrganizationID), definition.Name, string(definition.Type), definition.Required, string(allowedValues), foldName(definition.Name),
+		)
+
+		return err
+	})
+}
+
+func (repository *sqlUserAttributesRepository) GetAll() []models.UserAttributeDefinition {
+	return mustRead(queryAll(repository.store, "SELECT "+userAttributeColumns+" FROM user_attributes ORDER BY id", scanUserAttribute))
+}
+
+func (repository *sqlUserAttributesRepository) Delete(organizationID int, name string) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(tx, "DELETE FROM user_attributes WHERE COALESCE(organization_id, 0) = ? AND name_key = ?", organizationID, foldName(name))
+	})
+}
+
+const permissionColumns = "id, public_id, organization_id, name, description, deletable, created_at"
+
+func scanPermission(row row) (models.Permission, error) {
+	var permission models.Permission
+	var organizationID sql.NullInt64
+
+	err := row.Scan(
+		&permission.ID,
+		&permission.PublicID,
+		&organizationID,
+		&permission.Name,
+		&permission.Description,
+		&permission.Deletable,
+		&permission.CreatedAt,
+	)
+
+	permission.OrganizationID = int(organizationID.Int64)
+
+	return permission, err
+}
+
+// permissionValues returns the values of permissionColumns, followed by the
+// case-folded name.
+func permissionValues(permission models.Permission) []any {
+	return []any{
+		permission.ID,
+		permission.PublicID,
+		nullableOrganization(permission.OrganizationID),
+		permission.Name,
+		permission.Description,
+		permission.Deletable,
+		permission.CreatedAt,
+		foldName(permission.Name),
+	}
+}
+
+type sqlPermissionsRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlPermissionsRepository) Create(permission models.Permission) (int, error) {
+	err := repository.store.transaction(func(tx *sql.Tx) error {
+		id, err := repository.store.nextID(tx, "permissions")
+		if err != nil {
+			return err
+		}
+
+		permission.ID = id
+
+		_, err = repository.store.exec(tx, "INSERT INTO permissions ("+permissionColumns+", name_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", permissionValues(permission)...)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return permission.ID, nil
+}
+
+func (repository *sqlPermissionsRepository) GetByID(id int) *models.Permission {
+	return mustRead(queryOne(repository.store, "SELECT "+permissionColumns+" FROM permissions WHERE id = ?", scanPermission, id))
+}
+
+func (repository *sqlPermissionsRepository) GetByPublicID(publicID string) *models.Permission {
+	return mustRead(queryOne(repository.store, "SELECT "+permissionColumns+" FROM permissions WHERE public_id = ?", scanPermission, publicID))
+}
+
+func (repository *sqlPermissionsRepository) GetByName(name string) []models.Permission {
+	return mustRead(queryAll(repository.store, "SELECT "+permissionColumns+" FROM permissions WHERE name_key = ? ORDER BY id", scanPermission, foldName(name)))
+}
+
+func (repository *sqlPermissionsRepository) GetAll() []models.Permission {
+	return mustRead(queryAll(repository.store, "SELECT "+permissionColumns+" FROM permissions ORDER BY id", scanPermission))
+}
+
+func (repository *sqlPermissionsRepository) Update(permission models.Permission) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(
+			tx,
+			`UPDATE permissions SET public_id = ?, organization_id = ?, name = ?, description = ?, deletable = ?, created_at = ?, name_key = ?
+			WHERE id = ?`,
+			append(permissionValues(permission)[1:], permission.ID)...,
+		)
+	})
+}
+
+// Delete also removes the grants of the permission, through the foreign keys.
+func (repository *sqlPermissionsRepository) Delete(id int) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(tx, "DELETE FROM permissions WHERE id = ?", id)
+	})
+}
+
+const groupColumns = "id, organization_id, name, description, parent_id"
+
+func scanGroup(row row) (models.Group, error) {
+	var group models.Group
+	var parentID sql.NullInt64
+
+	err := row.Scan(&group.ID, &group.OrganizationID, &group.Name, &group.Description, &parentID)
+	group.ParentID = intPointer(parentID)
+
+	return group, err
+}
+
+// sqlGroupsRepository keeps the groups in the user_groups table, since
+// groups is a keyword in some databases, and the members in the order of
+// their IDs.
+type sqlGroupsRepository struct {
+	store *sqlStore
+}
+
+// exists reports whether the group with id exists, inside tx.
+func (repository *sqlGroupsRepository) exists(tx *sql.Tx, id int) (bool, error) {
+	var found int
+	err := tx.QueryRow(repository.store.dialect.rebind("SELECT id FROM user_groups WHERE id = ?"), id).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (repository *sqlGroupsRepository) Create(group models.Group) (int, error) {
+	err := repository.store.transaction(func(tx *sql.Tx) error {
+		if group.ParentID != nil {
+			exists, err := repository.exists(tx, *group.ParentID)
+			if err != nil {
+				return err
+			} else if !exists {
+				return ErrNotFound
+			}
+		}
+
+		id, err := repository.store.nextID(tx, "user_groups")
+		if err != nil {
+			return err
+		}
+
+		group.ID = id
+
+		_, err = repository.store.exec(
+			tx,
+			"INSERT INTO user_groups ("+groupColumns+", name_key) VALUES (?, ?, ?, ?, ?, ?)",
+			group.ID, group.OrganizationID, group.Name, group.Description, nullableInt(group.ParentID), foldName(group.Name),
+		)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return group.ID, nil
+}
+
+func (repository *sqlGroupsRepository) GetByID(id int) *models.Group {
+	return mustRead(queryOne(repository.store, "SELECT "+groupColumns+" FROM user_groups WHERE id = ?", scanGroup, id))
+}
+
+func (repository *sqlGroupsRepository) GetByName(name string) []models.Group {
+	return mustRead(queryAll(repository.store, "SELECT "+groupColumns+" FROM user_groups WHERE name_key = ? ORDER BY id", scanGroup, foldName(name)))
+}
+
+func (repository *sqlGroupsRepository) GetAll() []models.Group {
+	return mustRead(queryAll(repository.store, "SELECT "+groupColumns+" FROM user_groups ORDER BY id", scanGroup))
+}
+
+// Delete also removes the members and grants of the group, through the
+// foreign keys.
+func (repository *sqlGroupsRepository) Delete(id int) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(tx, "DELETE FROM user_groups WHERE id = ?", id)
+	})
+}
+
+func (repository *sqlGroupsRepository) GetMemberIDs(groupID int) []int {
+	return mustRead(queryAll(repository.store, "SELECT user_id FROM group_members WHERE group_id = ? ORDER BY id", scanID, groupID))
+}
+
+func (repository *sqlGroupsRepository) GetGroupIDsForUser(userID int) []int {
+	return mustRead(queryAll(repository.store, "SELECT group_id FROM group_members WHERE user_id = ? ORDER BY id", scanID, userID))
+}
+
+func (repository *sqlGroupsRepository) AddMembers(groupID int, userIDs []int) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		exists, err := repository.exists(tx, groupID)
+		if err != nil {
+			return err
+		} else if !exists {
+			return ErrNotFound
+		}
+
+		for _, userID := range userIDs {
+			id, err := repository.store.nextID(tx, "group_members")
+			if err != nil {
+				return err
+			}
+
+			_, err = repository.store.exec(tx, "INSERT INTO group_members (id, group_id, user_id) VALUES (?, ?, ?)", id, groupID, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repository *sqlGroupsRepository) RemoveMember(groupID, userID int) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(tx, "DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID)
+	})
+}
+
+func (repository *sqlGroupsRepository) DeleteMemberships(userID int) error {
+	_, err := repository.store.exec(repository.store.db, "DELETE FROM group_members WHERE user_id = ?", userID)
+	return err
+}
+
+func scanUserGrant(row row) (models.UserPermission, error) {
+	var grant models.UserPermission
+	err := row.Scan(&grant.UserID, &grant.PermissionID)
+
+	return grant, err
+}
+
+func scanGroupGrant(row row) (models.GroupPermission, error) {
+	var grant models.GroupPermission
+	err := row.Scan(&grant.GroupID, &grant.PermissionID)
+
+	return grant, err
+}
+
+// sqlGrantsRepository keeps the grants in the order of their IDs.
+type sqlGrantsRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlGrantsRepository) GetUserGrantsForUser(userID int) []models.UserPermission {
+	return mustRead(queryAll(repository.store, "SELECT user_id, permission_id FROM user_grants WHERE user_id = ? ORDER BY id", scanUserGrant, userID))
+}
+
+func (repository *sqlGrantsRepository) GetUserGrantsForPermission(permissionID int) []models.UserPermission {
+	return mustRead(queryAll(repository.store, "SELECT user_id, permission_id FROM user_grants WHERE permission_id = ? ORDER BY id", scanUserGrant, permissionID))
+}
+
+func (repository *sqlGrantsRepository) HasUserGrant(userID, permissionID int) bool {
+	return mustRead(queryOne(repository.store, "SELECT user_id, permission_id FROM user_grants WHERE user_id = ? AND permission_id = ?", scanUserGrant, userID, permissionID)) != nil
+}
+
+func (repository *sqlGrantsRepository) AddUserGrant(grant models.UserPermission) error {
+	return repository.ApplyUserGrants([]models.UserPermission{grant}, nil)
+}
+
+func (repository *sqlGrantsRepository) RemoveUserGrant(grant models.UserPermission) error {
+	return repository.ApplyUserGrants(nil, []models.UserPermission{grant})
+}
+
+func (repository *sqlGrantsRepository) ApplyUserGrants(added, removed []models.UserPermission) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		for _, grant := range removed {
+			err := repository.store.execOne(tx, "DELETE FROM user_grants WHERE user_id = ? AND permission_id = ?", grant.UserID, grant.PermissionID)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, grant := range added {
+			id, err := repository.store.nextID(tx, "user_grants")
+			if err != nil {
+				return err
+			}
+
+			_, err = repository.store.exec(tx, "INSERT INTO user_grants (id, user_id, permission_id) VALUES (?, ?, ?)", id, grant.UserID, grant.PermissionID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (repository *sqlGrantsRepository) DeleteUserGrants(userID int) error {
+	_, err := repository.store.exec(repository.store.db, "DELETE FROM user_grants WHERE user_id = ?", userID)
+	return err
+}
+
+func (repository *sqlGrantsRepository) GetGroupGrantsForGroup(groupID int) []models.GroupPermission {
+	return mustRead(queryAll(repository.store, "SELECT group_id, permission_id FROM group_grants WHERE group_id = ? ORDER BY id", scanGroupGrant, groupID))
+}
+
+func (repository *sqlGrantsRepository) GetGroupGrantsForPermission(permissionID int) []models.GroupPermission {
+	return mustRead(queryAll(repository.store, "SELECT group_id, permission_id FROM group_grants WHERE permission_id = ? ORDER BY id", scanGroupGrant, permissionID))
+}
+
+func (repository *sqlGrantsRepository) HasGroupGrant(groupID, permissionID int) bool {
+	return mustRead(queryOne(repository.store, "SELECT group_id, permission_id FROM group_grants WHERE group_id = ? AND permission_id = ?", scanGroupGrant, groupID, permissionID)) != nil
+}
+
+func (repository *sqlGrantsRepository) AddGroupGrant(grant models.GroupPermission) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		id, err := repository.store.nextID(tx, "group_grants")
+		if err != nil {
+			return err
+		}
+
+		_, err = repository.store.exec(tx, "INSERT INTO group_grants (id, group_id, permission_id) VALUES (?, ?, ?)", id, grant.GroupID, grant.PermissionID)
+
+		return err
+	})
+}
+
+func (repository *sqlGrantsRepository) RemoveGroupGrant(grant models.GroupPermission) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(tx, "DELETE FROM group_grants WHERE group_id = ? AND permission_id = ?", grant.GroupID, grant.PermissionID)
+	})
+}
+
+func (repository *sqlGrantsRepository) DeleteGroupGrants(groupID int) error {
+	_, err := repository.store.exec(repository.store.db, "DELETE FROM group_grants WHERE group_id = ?", groupID)
+	return err
+}
+
+func (repository *sqlGrantsRepository) DeletePermissionGrants(permissionID int) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		if _, err := repository.store.exec(tx, "DELETE FROM user_grants WHERE permission_id = ?", permissionID); err != nil {
+			return err
+		}
+
+		_, err := repository.store.exec(tx, "DELETE FROM group_grants WHERE permission_id = ?", permissionID)
+
+		return err
+	})
+}
+
+const accessRequestColumns = "id, organization_id, user_id, permission_id, justification, status, required_approvals, approved_by, denied_by, created_at, expires_at, resolved_at, resolution_reason"
+
+func scanAccessRequest(row row) (models.AccessRequest, error) {
+	var accessRequest models.AccessRequest
+	var approvedBy string
+	var deniedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&accessRequest.ID,
+		&accessRequest.OrganizationID,
+		&accessRequest.UserID,
+		&accessRequest.PermissionID,
+		&accessRequest.Justification,
+		&accessRequest.Status,
+		&accessRequest.RequiredApprovals,
+		&approvedBy,
+		&deniedBy,
+		&accessRequest.CreatedAt,
+		&accessRequest.ExpiresAt,
+		&resolvedAt,
+		&accessRequest.ResolutionReason,
+	)
+	if err != nil {
+		return accessRequest, err
+	}
+
+	if err := json.Unmarshal([]byte(approvedBy), &accessRequest.ApprovedBy); err != nil {
+		return accessRequest, err
+	}
+
+	accessRequest.DeniedBy = intPointer(deniedBy)
+	accessRequest.ResolvedAt = timePointer(resolvedAt)
+
+	return accessRequest, nil
+}
+
+// accessRequestValues returns the values of accessRequestColumns.
+func accessRequestValues(accessRequest models.AccessRequest) ([]any, error) {
+	approvedBy, err := json.Marshal(accessRequest.ApprovedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		accessRequest.ID,
+		accessRequest.OrganizationID,
+		accessRequest.UserID,
+		accessRequest.PermissionID,
+		accessRequest.Justification,
+		string(accessRequest.Status),
+		accessRequest.RequiredApprovals,
+		string(approvedBy),
+		nullableInt(accessRequest.DeniedBy),
+		accessRequest.CreatedAt,
+		accessRequest.ExpiresAt,
+		nullableTime(accessRequest.ResolvedAt),
+		accessRequest.ResolutionReason,
+	}, nil
+}
+
+type sqlAccessRequestsRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlAccessRequestsRepository) Create(accessRequest models.AccessRequest) (int, error) {
+	err := repository.store.transaction(func(tx *sql.Tx) error {
+		id, err := repository.store.nextID(tx, "access_requests")
+		if err != nil {
+			return err
+		}
+
+		accessRequest.ID = id
+
+		values, err := accessRequestValues(accessRequest)
+		if err != nil {
+			return err
+		}
+
+		_, err = repository.store.exec(tx, "INSERT INTO access_requests ("+accessRequestColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", values...)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return accessRequest.ID, nil
+}
+
+func (repository *sqlAccessRequestsRepository) GetByID(id int) *models.AccessRequest {
+	return mustRead(queryOne(repository.store, "SELECT "+accessRequestColumns+" FROM access_requests WHERE id = ?", scanAccessRequest, id))
+}
+
+func (repository *sqlAccessRequestsRepository) GetAll() []models.AccessRequest {
+	return mustRead(queryAll(repository.store, "SELECT "+accessRequestColumns+" FROM access_requests ORDER BY id", scanAccessRequest))
+}
+
+func (repository *sqlAccessRequestsRepository) Update(accessRequest models.AccessRequest) error {
+	values, err := accessRequestValues(accessRequest)
+	if err != nil {
+		return err
+	}
+
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		return repository.store.execOne(
+			tx,
+			`UPDATE access_requests SET organization_id = ?, user_id = ?, permission_id = ?, justification = ?, status = ?, required_approvals = ?,
+				approved_by = ?, denied_by = ?, created_at = ?, expires_at = ?, resolved_at = ?, resolution_reason = ?
+			WHERE id = ?`,
+			append(values[1:], accessRequest.ID)...,
+		)
+	})
+}
+
+type sqlSettingsRepository struct {
+	store *sqlStore
+}
+
+func (repository *sqlSettingsRepository) Get(name string) (string, bool) {
+	value := mustRead(queryOne(repository.store, "SELECT value FROM settings WHERE name = ?", func(row row) (string, error) {
+		var value string
+		err := row.Scan(&value)
+
+		return value, err
+	}, name))
+	if value == nil {
+		return "", false
+	}
+
+	return *value, true
+}
+
+func (repository *sqlSettingsRepository) Set(name, value string) error {
+	return repository.store.transaction(func(tx *sql.Tx) error {
+		_, err := repository.store.exec(tx, "INSERT INTO settings (name, value) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET value = excluded.value", name, value)
+
+		return err
+	})
+}
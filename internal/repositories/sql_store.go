@@ -0,0 +1,298 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-crud-gin/internal/platform/logger"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect is the SQL flavour of the database of a SQL store. The queries are
+// portable SQL with ? placeholders, rewritten when the dialect needs it.
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// rebind replaces the ? placeholders of query with the ones of the dialect.
+func (dialect Dialect) rebind(query string) string {
+	if dialect != DialectPostgres {
+		return query
+	}
+
+	var builder strings.Builder
+	parameter := 0
+	for _, r := range query {
+		if r != '?' {
+			builder.WriteRune(r)
+			continue
+		}
+
+		parameter++
+		builder.WriteString("$" + strconv.Itoa(parameter))
+	}
+
+	return builder.String()
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint,
+// going by the error codes of the drivers.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+
+	var postgresErr *pq.Error
+	if errors.As(err, &postgresErr) {
+		return postgresErr.Code == "23505"
+	}
+
+	return false
+}
+
+type SQLStoreOptions struct {
+	Dialect Dialect
+
+	// MigrateOnStart applies the pending migrations when the store is
+	// created. Otherwise they are applied with cmd/migrate, and the store
+	// refuses a database whose schema is not up to date.
+	MigrateOnStart bool
+}
+
+func DefaultSQLStoreOptions() SQLStoreOptions {
+	return SQLStoreOptions{
+		Dialect:        DialectSQLite,
+		MigrateOnStart: true,
+	}
+}
+
+// executor runs statements on the database or inside a transaction.
+type executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// row is a *sql.Row or the current row of *sql.Rows.
+type row interface {
+	Scan(dest ...any) error
+}
+
+// sqlStore keeps everything in a database, whose foreign keys tie every
+// entity to its organization. The failed reads panic with a *ReadError.
+type sqlStore struct {
+	db      *sql.DB
+	dialect Dialect
+
+	organizations  *sqlOrganizationsRepository
+	users          *sqlUsersRepository
+	userAttributes *sqlUserAttributesRepository
+	permissions    *sqlPermissionsRepository
+	groups         *sqlGroupsRepository
+	grants         *sqlGrantsRepository
+	accessRequests *sqlAccessRequestsRepository
+	settings       *sqlSettingsRepository
+}
+
+func (store *sqlStore) Organizations() OrganizationsRepository {
+	return store.organizations
+}
+
+func (store *sqlStore) Users() UsersRepository {
+	return store.users
+}
+
+func (store *sqlStore) UserAttributes() UserAttributesRepository {
+	return store.userAttributes
+}
+
+func (store *sqlStore) Permissions() PermissionsRepository {
+	return store.permissions
+}
+
+func (store *sqlStore) Groups() GroupsRepository {
+	return store.groups
+}
+
+func (store *sqlStore) Grants() GrantsRepository {
+	return store.grants
+}
+
+func (store *sqlStore) AccessRequests() AccessRequestsRepository {
+	return store.accessRequests
+}
+
+func (store *sqlStore) Settings() SettingsRepository {
+	return store.settings
+}
+
+func (store *sqlStore) exec(executor executor, query string, args ...any) (sql.Result, error) {
+	return executor.Exec(store.dialect.rebind(query), args...)
+}
+
+// execOne runs a query that must change a row, or returns ErrNotFound.
+func (store *sqlStore) execOne(executor executor, query string, args ...any) error {
+	result, err := store.exec(executor, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// queryOne scans the first row of query with scan, and returns nil when
+// there is none.
+func queryOne[T any](store *sqlStore, query string, scan func(row row) (T, error), args ...any) (*T, error) {
+	value, err := scan(store.db.QueryRow(store.dialect.rebind(query), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// queryAll scans every row of query with scan.
+func queryAll[T any](store *sqlStore, query string, scan func(row row) (T, error), args ...any) ([]T, error) {
+	rows, err := store.db.Query(store.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	values := []T{}
+	for rows.Next() {
+		value, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// ReadError is the panic value of the reads of a SQL store that fail. The
+// repositories cannot return errors on reads, and reading the failure as a
+// missing entity would let callers act on it, e.g. taking a username that is
+// in use, so the request is aborted instead. gin answers it with a 500.
+type ReadError struct {
+	Err error
+}
+
+func (err *ReadError) Error() string {
+	return fmt.Sprintf("SQL store read failed: %v", err.Err)
+}
+
+func (err *ReadError) Unwrap() error {
+	return err.Err
+}
+
+// mustRead returns value, or panics with a *ReadError when err is not nil.
+func mustRead[T any](value T, err error) T {
+	if err != nil {
+		panic(&ReadError{Err: err})
+	}
+
+	return value
+}
+
+// transaction runs change in a transaction, committed only when change
+// succeeds. Unique constraint violations are returned as ErrAlreadyExists.
+func (store *sqlStore) transaction(change func(tx *sql.Tx) error) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := change(tx); err != nil {
+		tx.Rollback()
+
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// nextID takes the next ID of sequence inside tx.
+func (store *sqlStore) nextID(tx *sql.Tx, sequence string) (int, error) {
+	if _, err := store.exec(tx, "UPDATE sequences SET last_id = last_id + 1 WHERE name = ?", sequence); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := tx.QueryRow(store.dialect.rebind("SELECT last_id FROM sequences WHERE name = ?"), sequence).Scan(&id)
+
+	return id, err
+}
+
+// NewSQLStore returns a store that keeps everything in db, which must be a
+// database of options.Dialect.
+func NewSQLStore(logger logger.Logger, options SQLStoreOptions, db *sql.DB) (Store, error) {
+	latest, err := LatestSchemaVersion(options.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.MigrateOnStart {
+		applied, err := MigrateUp(db, options.Dialect)
+		if err != nil {
+			return nil, err
+		}
+
+		if applied > 0 {
+			logger.Infof("[SQLStore] %d migrations applied!", applied)
+		}
+	}
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != latest {
+		return nil, fmt.Errorf("database schema is at version %d but the store needs version %d", version, latest)
+	}
+
+	store := &sqlStore{
+		db:      db,
+		dialect: options.Dialect,
+	}
+
+	store.organizations = &sqlOrganizationsRepository{store: store}
+	store.users = &sqlUsersRepository{store: store}
+	store.userAttributes = &sqlUserAttributesRepository{store: store}
+	store.permissions = &sqlPermissionsRepository{store: store}
+	store.groups = &sqlGroupsRepository{store: store}
+	store.grants = &sqlGrantsRepository{store: store}
+	store.accessRequests = &sqlAccessRequestsRepository{store: store}
+	store.settings = &sqlSettingsRepository{store: store}
+
+	return store, nil
+}
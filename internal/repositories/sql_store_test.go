@@ -0,0 +1,385 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/database"
+	"go-crud-gin/internal/platform/logger"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func openTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.Open("sqlite", filepath.Join(t.TempDir(), "store.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// openTestSQLStore returns a store on a new SQLite database with the default
+// organization, which owns the users the tests create.
+func openTestSQLStore(t *testing.T) Store {
+	t.Helper()
+
+	store, err := NewSQLStore(logger.NewLocalLogger(), DefaultSQLStoreOptions(), openTestDatabase(t))
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+
+	if _, err := store.Organizations().Create(models.Organization{Name: "default"}); err != nil {
+		t.Fatalf("Create organization: %v", err)
+	}
+
+	return store
+}
+
+func createTestSQLUser(t *testing.T, store Store, username string) int {
+	t.Helper()
+
+	userID, err := store.Users().Create(models.User{
+		PublicID:       "public-" + username,
+		OrganizationID: 1,
+		Username:       username,
+		PasswordHash:   "hash-of-" + username,
+		Attributes:     map[string]any{"team": "core"},
+		Status:         models.UserActive,
+		CreatedAt:      time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Create %s: %v", username, err)
+	}
+
+	return userID
+}
+
+func tableNames(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		t.Fatalf("Query tables: %v", err)
+	}
+
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan table: %v", err)
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestSQLMigrationsGoUpAndDown(t *testing.T) {
+	db := openTestDatabase(t)
+
+	latest, err := LatestSchemaVersion(DialectSQLite)
+	if err != nil {
+		t.Fatalf("LatestSchemaVersion: %v", err)
+	}
+
+	for round := 0; round < 2; round++ {
+		applied, err := MigrateUp(db, DialectSQLite)
+		if err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+
+		if applied != latest {
+			t.Fatalf("MigrateUp applied %d migrations, want %d", applied, latest)
+		}
+
+		if version, _ := SchemaVersion(db); version != latest {
+			t.Fatalf("schema at version %d after MigrateUp, want %d", version, latest)
+		}
+
+		reverted, err := MigrateDown(db, DialectSQLite, latest)
+		if err != nil {
+			t.Fatalf("MigrateDown: %v", err)
+		}
+
+		if reverted != latest {
+			t.Fatalf("MigrateDown reverted %d migrations, want %d", reverted, latest)
+		}
+
+		if names := tableNames(t, db); !slices.Equal(names, []string{"schema_migrations"}) {
+			t.Fatalf("tables left after MigrateDown: %v", names)
+		}
+	}
+}
+
+func TestSQLMigrationsKeepTheDataOfTheFirstVersion(t *testing.T) {
+	db := openTestDatabase(t)
+
+	if _, err := MigrateUp(db, DialectSQLite); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+
+	latest, _ := LatestSchemaVersion(DialectSQLite)
+	if _, err := MigrateDown(db, DialectSQLite, latest-1); err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+
+	// The rows of a database created by the first version, where the global
+	// permissions belonged to organization 0. The keys are folded by
+	// foldName.
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, statement := range []string{
+		"UPDATE sequences SET last_id = 1",
+		`INSERT INTO users VALUES (1, 'public-ana', 1, 'Ana', 'ANA', '', '', NULL, '', '{}', 'active', '', NULL, 'hash', ?, NULL)`,
+		"INSERT INTO permissions VALUES (1, 'public-reports', 0, 'reports', 'REPORTS', '', 1, ?)",
+		"INSERT INTO user_grants VALUES (1, 1, 1)",
+	} {
+		args := []any{}
+		if slices.Contains([]byte(statement), '?') {
+			args = append(args, now)
+		}
+
+		if _, err := db.Exec(statement, args...); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+
+	store, err := NewSQLStore(logger.NewLocalLogger(), DefaultSQLStoreOptions(), db)
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+
+	if organization := store.Organizations().GetByID(1); organization == nil || organization.Name != "default" {
+		t.Fatalf("default organization = %+v", organization)
+	}
+
+	if user := store.Users().GetByUsername("ANA"); user == nil || user.OrganizationID != 1 {
+		t.Fatalf("user after migrating = %+v", user)
+	}
+
+	if permission := store.Permissions().GetByID(1); permission == nil || permission.OrganizationID != 0 {
+		t.Fatalf("global permission after migrating = %+v", permission)
+	}
+
+	if !store.Grants().HasUserGrant(1, 1) {
+		t.Fatalf("grant lost while migrating")
+	}
+
+	// The next IDs must not collide with the migrated rows.
+	if organizationID, err := store.Organizations().Create(models.Organization{Name: "acme"}); err != nil || organizationID != 2 {
+		t.Fatalf("Create organization = %d, %v, want 2", organizationID, err)
+	}
+
+	if _, err := MigrateDown(db, DialectSQLite, latest-1); err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+
+	var organizationID, grants int
+	if err := db.QueryRow("SELECT organization_id FROM permissions WHERE id = 1").Scan(&organizationID); err != nil || organizationID != 0 {
+		t.Fatalf("global permission after reverting = %d, %v", organizationID, err)
+	}
+
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_grants").Scan(&grants); err != nil || grants != 1 {
+		t.Fatalf("grants after reverting = %d, %v", grants, err)
+	}
+}
+
+func TestSQLStoreRoundTrips(t *testing.T) {
+	store := openTestSQLStore(t)
+
+	suspendedUntil := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	user := models.User{
+		PublicID:         "public-ana",
+		OrganizationID:   1,
+		Username:         "Ana",
+		DisplayName:      "Ana Pérez",
+		Email:            "ana@example.com",
+		Locale:           "es",
+		Attributes:       map[string]any{"team": "core"},
+		Status:           models.UserSuspended,
+		SuspensionReason: "spam",
+		SuspendedUntil:   &suspendedUntil,
+		PasswordHash:     "hash",
+		CreatedAt:        time.Now().UTC().Truncate(time.Second),
+	}
+
+	userID, err := store.Users().Create(user)
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	user.ID = userID
+	if got := store.Users().GetByID(userID); got == nil || !reflect.DeepEqual(*got, user) {
+		t.Fatalf("GetByID = %+v, want %+v", got, user)
+	}
+
+	user.DisplayName = "Ana P."
+	user.Status = models.UserActive
+	user.SuspensionReason = ""
+	user.SuspendedUntil = nil
+	if err := store.Users().Update(user); err != nil {
+		t.Fatalf("Update user: %v", err)
+	}
+
+	if got := store.Users().GetByEmail("ANA@example.com"); got == nil || !reflect.DeepEqual(*got, user) {
+		t.Fatalf("GetByEmail after Update = %+v, want %+v", got, user)
+	}
+
+	permission := models.Permission{
+		PublicID:    "public-reports",
+		Name:        "reports",
+		Description: "Reports",
+		Deletable:   true,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	permissionID, err := store.Permissions().Create(permission)
+	if err != nil {
+		t.Fatalf("Create permission: %v", err)
+	}
+
+	permission.ID = permissionID
+	if got := store.Permissions().GetByName("REPORTS"); !reflect.DeepEqual(got, []models.Permission{permission}) {
+		t.Fatalf("GetByName = %+v, want %+v", got, permission)
+	}
+
+	permission.Name = "reports_read"
+	if err := store.Permissions().Update(permission); err != nil {
+		t.Fatalf("Update permission: %v", err)
+	}
+
+	if got := store.Permissions().GetByPublicID(permission.PublicID); got == nil || !reflect.DeepEqual(*got, permission) {
+		t.Fatalf("GetByPublicID after Update = %+v, want %+v", got, permission)
+	}
+
+	grant := models.UserPermission{UserID: userID, PermissionID: permissionID}
+	if err := store.Grants().AddUserGrant(grant); err != nil {
+		t.Fatalf("AddUserGrant: %v", err)
+	}
+
+	if err := store.Grants().AddUserGrant(grant); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("AddUserGrant twice = %v, want ErrAlreadyExists", err)
+	}
+
+	if got := store.Grants().GetUserGrantsForUser(userID); !reflect.DeepEqual(got, []models.UserPermission{grant}) {
+		t.Fatalf("GetUserGrantsForUser = %+v, want %+v", got, grant)
+	}
+
+	if err := store.Grants().RemoveUserGrant(grant); err != nil {
+		t.Fatalf("RemoveUserGrant: %v", err)
+	}
+
+	if store.Grants().HasUserGrant(userID, permissionID) {
+		t.Fatalf("grant kept after RemoveUserGrant")
+	}
+
+	if err := store.Users().Delete(userID); err != nil {
+		t.Fatalf("Delete user: %v", err)
+	}
+
+	if err := store.Permissions().Delete(permissionID); err != nil {
+		t.Fatalf("Delete permission: %v", err)
+	}
+
+	if store.Users().GetByID(userID) != nil || store.Permissions().GetByID(permissionID) != nil {
+		t.Fatalf("deleted rows still found")
+	}
+}
+
+func TestSQLStoreUsernamesAreUniqueIgnoringCase(t *testing.T) {
+	store := openTestSQLStore(t)
+
+	userID := createTestSQLUser(t, store, "Ana")
+
+	_, err := store.Users().Create(models.User{
+		PublicID:       "public-other",
+		OrganizationID: 1,
+		Username:       "ANA",
+		Attributes:     map[string]any{},
+		Status:         models.UserActive,
+	})
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("Create ANA = %v, want ErrAlreadyExists", err)
+	}
+
+	if user := store.Users().GetByUsername("aNa"); user == nil || user.ID != userID || user.Username != "Ana" {
+		t.Fatalf("GetByUsername(aNa) = %+v", user)
+	}
+
+	// Renaming onto a name in use fails too.
+	otherID := createTestSQLUser(t, store, "Bea")
+	other := store.Users().GetByID(otherID)
+	other.Username = "ana"
+	if err := store.Users().Update(*other); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("Update to ana = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestSQLStoreCascadesDeletes(t *testing.T) {
+	store := openTestSQLStore(t)
+
+	userID := createTestSQLUser(t, store, "ana")
+	otherID := createTestSQLUser(t, store, "bea")
+	permissionID := createTestPermission(t, store, "reports")
+
+	groupID, err := store.Groups().Create(models.Group{OrganizationID: 1, Name: "team"})
+	if err != nil {
+		t.Fatalf("Create group: %v", err)
+	}
+
+	if err := store.Groups().AddMembers(groupID, []int{userID, otherID}); err != nil {
+		t.Fatalf("AddMembers: %v", err)
+	}
+
+	for _, id := range []int{userID, otherID} {
+		if err := store.Grants().AddUserGrant(models.UserPermission{UserID: id, PermissionID: permissionID}); err != nil {
+			t.Fatalf("AddUserGrant: %v", err)
+		}
+	}
+
+	if err := store.Grants().AddGroupGrant(models.GroupPermission{GroupID: groupID, PermissionID: permissionID}); err != nil {
+		t.Fatalf("AddGroupGrant: %v", err)
+	}
+
+	if err := store.Users().Delete(userID); err != nil {
+		t.Fatalf("Delete user: %v", err)
+	}
+
+	if got := store.Groups().GetMemberIDs(groupID); !slices.Equal(got, []int{otherID}) {
+		t.Fatalf("members after deleting a user = %v, want [%d]", got, otherID)
+	}
+
+	if got := store.Grants().GetUserGrantsForPermission(permissionID); len(got) != 1 || got[0].UserID != otherID {
+		t.Fatalf("grants after deleting a user = %+v", got)
+	}
+
+	if err := store.Groups().Delete(groupID); err != nil {
+		t.Fatalf("Delete group: %v", err)
+	}
+
+	if got := store.Groups().GetGroupIDsForUser(otherID); len(got) != 0 {
+		t.Fatalf("memberships after deleting the group = %v", got)
+	}
+
+	if got := store.Grants().GetGroupGrantsForPermission(permissionID); len(got) != 0 {
+		t.Fatalf("group grants after deleting the group = %+v", got)
+	}
+
+	if err := store.Permissions().Delete(permissionID); err != nil {
+		t.Fatalf("Delete permission: %v", err)
+	}
+
+	if store.Grants().HasUserGrant(otherID, permissionID) {
+		t.Fatalf("grant kept after deleting the permission")
+	}
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("entity not found")
+	ErrAlreadyExists = errors.New("entity already exists")
+)
+
+// Store keeps everything the services own. Stores own the integer IDs, which
+// are never reused, while the services check every business rule before
+// writing. Stores must be safe for concurrent use.
+type Store interface {
+	Organizations() OrganizationsRepository
+	Users() UsersRepository
+	UserAttributes() UserAttributesRepository
+	Permissions() PermissionsRepository
+	Groups() GroupsRepository
+	Grants() GrantsRepository
+	AccessRequests() AccessRequestsRepository
+	Settings() SettingsRepository
+}
+
+type memoryStore struct {
+	organizations  OrganizationsRepository
+	users          UsersRepository
+	userAttributes UserAttributesRepository
+	permissions    PermissionsRepository
+	groups         GroupsRepository
+	grants         GrantsRepository
+	accessRequests AccessRequestsRepository
+	settings       SettingsRepository
+}
+
+func (store *memoryStore) Organizations() OrganizationsRepository {
+	return store.organizations
+}
+
+func (store *memoryStore) Users() UsersRepository {
+	return store.users
+}
+
+func (store *memoryStore) UserAttributes() UserAttributesRepository {
+	return store.userAttributes
+}
+
+func (store *memoryStore) Permissions() PermissionsRepository {
+	return store.permissions
+}
+
+func (store *memoryStore) Groups() GroupsRepository {
+	return store.groups
+}
+
+func (store *memoryStore) Grants() GrantsRepository {
+	return store.grants
+}
+
+func (store *memoryStore) AccessRequests() AccessRequestsRepository {
+	return store.accessRequests
+}
+
+func (store *memoryStore) Settings() SettingsRepository {
+	return store.settings
+}
+
+// NewMemoryStore returns a store that keeps everything in memory, so all data
+// is lost when the process ends.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		organizations:  NewMemoryOrganizationsRepository(),
+		users:          NewMemoryUsersRepository(),
+		userAttributes: NewMemoryUserAttributesRepository(),
+		permissions:    NewMemoryPermissionsRepository(),
+		groups:         NewMemoryGroupsRepository(),
+		grants:         NewMemoryGrantsRepository(),
+		accessRequests: NewMemoryAccessRequestsRepository(),
+		settings:       NewMemorySettingsRepository(),
+	}
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"go-crud-gin/internal/models"
+	"slices"
+	"sync"
+)
+
+// UserAttributesRepository keeps the definitions of the custom attributes of
+// users, with names unique inside each organization, compared
+// case-insensitively.
+type UserAttributesRepository interface {
+	// Save creates the definition, or replaces the one with the same name in
+	// its organization keeping its place in GetAll.
+	Save(definition models.UserAttributeDefinition) error
+	GetAll() []models.UserAttributeDefinition
+	Delete(organizationID int, name string) error
+}
+
+type memoryUserAttributesRepository struct {
+	mutex       sync.RWMutex
+	definitions []models.UserAttributeDefinition
+}
+
+// cloneDefinition copies the allowed values too, so callers cannot change the
+// stored definition.
+func cloneDefinition(definition models.UserAttributeDefinition) models.UserAttributeDefinition {
+	definition.AllowedValues = slices.Clone(definition.AllowedValues)
+	return definition
+}
+
+func (repository *memoryUserAttributesRepository) find(organizationID int, name string) int {
+	return slices.IndexFunc(repository.definitions, func(definition models.UserAttributeDefinition) bool {
+		return definition.OrganizationID == organizationID && foldName(definition.Name) == foldName(name)
+	})
+}
+
+func (repository *memoryUserAttributesRepository) Save(definition models.UserAttributeDefinition) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if index := repository.find(definition.OrganizationID, definition.Name); index >= 0 {
+		repository.definitions[index] = cloneDefinition(definition)
+	} else {
+		repository.definitions = append(repository.definitions, cloneDefinition(definition))
+	}
+
+	return nil
+}
+
+func (repository *memoryUserAttributesRepository) GetAll() []models.UserAttributeDefinition {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	definitions := make([]models.UserAttributeDefinition, len(repository.definitions))
+	for i, definition := range repository.definitions {
+		definitions[i] = cloneDefinition(definition)
+	}
+
+	return definitions
+}
+
+func (repository *memoryUserAttributesRepository) Delete(organizationID int, name string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	index := repository.find(organizationID, name)
+	if index < 0 {
+		return ErrNotFound
+	}
+
+	repository.definitions = slices.Delete(repository.definitions, index, index+1)
+
+	return nil
+}
+
+func NewMemoryUserAttributesRepository() UserAttributesRepository {
+	return newMemoryUserAttributesRepository()
+}
+
+func newMemoryUserAttributesRepository() *memoryUserAttributesRepository {
+	return &memoryUserAttributesRepository{
+		definitions: []models.UserAttributeDefinition{},
+	}
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"go-crud-gin/internal/models"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// UsersRepository lookups include the deleted users, since their usernames
+// stay reserved until they are purged. Usernames and emails are compared
+// case-insensitively.
+type UsersRepository interface {
+	Create(user models.User) (int, error)
+	GetByID(id int) *models.User
+	GetByPublicID(publicID string) *models.User
+	GetByUsername(username string) *models.User
+	GetByEmail(email string) *models.User
+	GetAll() []models.User
+	Update(user models.User) error
+	Delete(id int) error
+}
+
+// memoryUsersRepository indexes the users by every field they are looked up
+// by, so lookups take the same time whatever the number of users.
+type memoryUsersRepository struct {
+	mutex  sync.RWMutex
+	lastID int
+
+	// ids keeps the creation order for GetAll.
+	ids   []int
+	users map[int]models.User
+
+	idsByPublicID map[string]int
+	idsByUsername map[string]int
+	idsByEmail    map[string]int
+}
+
+// cloneUser copies the attributes too, so callers cannot change the stored user.
+func cloneUser(user models.User) models.User {
+	user.Attributes = maps.Clone(user.Attributes)
+	return user
+}
+
+// get returns nil for the zero ID the indexes give for missing keys.
+func (repository *memoryUsersRepository) get(id int) *models.User {
+	user, ok := repository.users[id]
+	if !ok {
+		return nil
+	}
+
+	user = cloneUser(user)
+
+	return &user
+}
+
+func (repository *memoryUsersRepository) index(user models.User) {
+	repository.users[user.ID] = cloneUser(user)
+	repository.idsByPublicID[user.PublicID] = user.ID
+	repository.idsByUsername[foldName(user.Username)] = user.ID
+	if user.Email != "" {
+		repository.idsByEmail[foldName(user.Email)] = user.ID
+	}
+}
+
+func (repository *memoryUsersRepository) unindex(user models.User) {
+	delete(repository.users, user.ID)
+	delete(repository.idsByPublicID, user.PublicID)
+	delete(repository.idsByUsername, foldName(user.Username))
+	if id, ok := repository.idsByEmail[foldName(user.Email)]; ok && id == user.ID {
+		delete(repository.idsByEmail, foldName(user.Email))
+	}
+}
+
+func (repository *memoryUsersRepository) Create(user models.User) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.idsByUsername[foldName(user.Username)]; ok {
+		return 0, ErrAlreadyExists
+	}
+
+	repository.lastID++
+	user.ID = repository.lastID
+
+	repository.ids = append(repository.ids, user.ID)
+	repository.index(user)
+
+	return user.ID, nil
+}
+
+func (repository *memoryUsersRepository) GetByID(id int) *models.User {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(id)
+}
+
+func (repository *memoryUsersRepository) GetByPublicID(publicID string) *models.User {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(repository.idsByPublicID[publicID])
+}
+
+func (repository *memoryUsersRepository) GetByUsername(username string) *models.User {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.get(repository.idsByUsername[foldName(username)])
+}
+
+func (repository *memoryUsersRepository) GetByEmail(email string) *models.User {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	if email == "" {
+		return nil
+	}
+
+	return repository.get(repository.idsByEmail[foldName(email)])
+}
+
+func (repository *memoryUsersRepository) GetAll() []models.User {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	users := make([]models.User, len(repository.ids))
+	for i, id := range repository.ids {
+		users[i] = cloneUser(repository.users[id])
+	}
+
+	return users
+}
+
+func (repository *memoryUsersRepository) Update(user models.User) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	current, ok := repository.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if id, ok := repository.idsByUsername[foldName(user.Username)]; ok && id != user.ID {
+		return ErrAlreadyExists
+	}
+
+	repository.unindex(current)
+	repository.index(user)
+
+	return nil
+}
+
+func (repository *memoryUsersRepository) Delete(id int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	user, ok := repository.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	repository.unindex(user)
+
+	// IDs are sorted, since they only grow.
+	if index, found := slices.BinarySearch(repository.ids, id); found {
+		repository.ids = slices.Delete(repository.ids, index, index+1)
+	}
+
+	return nil
+}
+
+func NewMemoryUsersRepository() UsersRepository {
+	return newMemoryUsersRepository()
+}
+
+func newMemoryUsersRepository() *memoryUsersRepository {
+	return &memoryUsersRepository{
+		ids:           []int{},
+		users:         map[int]models.User{},
+		idsByPublicID: map[string]int{},
+		idsByUsername: map[string]int{},
+		idsByEmail:    map[string]int{},
+	}
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"fmt"
+	"go-crud-gin/internal/models"
+	"testing"
+)
+
+// benchmarkSizes are the numbers of users the benchmarks run with. Lookups
+// must take the same time at every size.
+var benchmarkSizes = []int{1_000, 10_000, 100_000}
+
+// benchmarkUsers caches the repositories by size, since the benchmarks run
+// several times and filling the largest ones takes a while.
+var benchmarkUsers = map[int]UsersRepository{}
+
+func newBenchmarkUsers(b *testing.B, size int) UsersRepository {
+	b.Helper()
+
+	if users, ok := benchmarkUsers[size]; ok {
+		return users
+	}
+
+	users := NewMemoryUsersRepository()
+	for i := 0; i < size; i++ {
+		_, err := users.Create(models.User{
+			PublicID:   fmt.Sprintf("public-%d", i),
+			Username:   fmt.Sprintf("user%d", i),
+			Attributes: map[string]any{},
+		})
+		if err != nil {
+			b.Fatalf("Create user%d: %v", i, err)
+		}
+	}
+
+	benchmarkUsers[size] = users
+
+	return users
+}
+
+func BenchmarkUsersGetByID(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("users=%d", size), func(b *testing.B) {
+			users := newBenchmarkUsers(b, size)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if users.GetByID(i%size+1) == nil {
+					b.Fatalf("user %d not found", i%size+1)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUsersGetByUsername(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("users=%d", size), func(b *testing.B) {
+			users := newBenchmarkUsers(b, size)
+			usernames := make([]string, size)
+			for i := range usernames {
+				usernames[i] = fmt.Sprintf("USER%d", i)
+			}
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if users.GetByUsername(usernames[i%size]) == nil {
+					b.Fatalf("%s not found", usernames[i%size])
+				}
+			}
+		})
+	}
+}
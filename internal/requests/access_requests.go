@@ -0,0 +1,6 @@
+package requests
+
+type CreateAccessRequest struct {
+	PermissionName string `json:"permission_name"`
+	Justification  string `json:"justification"`
+}
@@ -6,6 +6,7 @@ type LogInRequest struct {
 }
 
 type SignUpRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username         string  `json:"username"`
+	Password         string  `json:"password"`
+	OrganizationName *string `json:"organization_name"`
 }
@@ -0,0 +1,24 @@
+package requests
+
+import "go-crud-gin/internal/models"
+
+type AuthorizationAction struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// AuthorizationCheck asks for either a list of permissions, of which the
+// subject needs any, or for an action whose requirements come from the
+// routes of this service. The subject must also have every attribute value
+// in SubjectAttributes.
+type AuthorizationCheck struct {
+	Subject           string                        `json:"subject"`
+	Permissions       []string                      `json:"permissions"`
+	Action            *AuthorizationAction          `json:"action"`
+	Resource          *models.AuthorizationResource `json:"resource"`
+	SubjectAttributes map[string]any                `json:"subject_attributes"`
+}
+
+type BatchAuthorizationCheck struct {
+	Checks []AuthorizationCheck `json:"checks"`
+}
@@ -0,0 +1,11 @@
+package requests
+
+type CreateGroup struct {
+	GroupName       string  `json:"group_name"`
+	Description     string  `json:"description"`
+	ParentGroupName *string `json:"parent_group_name"`
+}
+
+type AddGroupMembers struct {
+	Usernames []string `json:"usernames"`
+}
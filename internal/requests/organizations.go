@@ -0,0 +1,5 @@
+package requests
+
+type CreateOrganization struct {
+	OrganizationName string `json:"organization_name"`
+}
@@ -4,3 +4,19 @@ type CreatePermission struct {
 	PermissionName string `json:"permission_name"`
 	Description    string `json:"description"`
 }
+
+type UpdatePermission struct {
+	PermissionName *string `json:"permission_name"`
+	Description    *string `json:"description"`
+}
+
+type BulkUserPermissionOperation struct {
+	Username       string `json:"username"`
+	PermissionName string `json:"permission_name"`
+	Action         string `json:"action"`
+}
+
+type BulkUserPermissions struct {
+	DryRun     bool                          `json:"dry_run"`
+	Operations []BulkUserPermissionOperation `json:"operations"`
+}
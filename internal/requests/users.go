@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"go-crud-gin/internal/models"
+	"time"
+)
+
+type UpdateUser struct {
+	Username    *string        `json:"username"`
+	Password    *string        `json:"password"`
+	DisplayName *string        `json:"display_name"`
+	Email       *string        `json:"email"`
+	Locale      *string        `json:"locale"`
+	Attributes  map[string]any `json:"attributes"`
+}
+
+type SetUserAttribute struct {
+	Type          string `json:"type"`
+	Required      bool   `json:"required"`
+	AllowedValues []any  `json:"allowed_values"`
+}
+
+type SuspendUser struct {
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"`
+}
+
+type ImportUsers struct {
+	Users []models.UserImportRow `json:"users"`
+}
@@ -0,0 +1,21 @@
+package responses
+
+import (
+	"go-crud-gin/internal/models"
+	"time"
+)
+
+type AccessRequestResponse struct {
+	ID                int                        `json:"id"`
+	Username          string                     `json:"username"`
+	PermissionName    string                     `json:"permission_name"`
+	Justification     string                     `json:"justification"`
+	Status            models.AccessRequestStatus `json:"status"`
+	RequiredApprovals int                        `json:"required_approvals"`
+	ApprovedBy        []string                   `json:"approved_by"`
+	DeniedBy          *string                    `json:"denied_by,omitempty"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	ExpiresAt         time.Time                  `json:"expires_at"`
+	ResolvedAt        *time.Time                 `json:"resolved_at,omitempty"`
+	ResolutionReason  string                     `json:"resolution_reason,omitempty"`
+}
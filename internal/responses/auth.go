@@ -6,5 +6,5 @@ type LogInResponse struct {
 
 type SignUpResponse struct {
 	AccessToken string `json:"access_token"`
-	UserID      int    `json:"user_id"`
+	UserID      string `json:"user_id"`
 }
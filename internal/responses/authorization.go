@@ -0,0 +1,23 @@
+package responses
+
+import "go-crud-gin/internal/models"
+
+type AuthorizationCheckResponse struct {
+	models.AuthorizationDecision
+
+	Subject string `json:"subject"`
+
+	// MatchedRoute is the route template that matched the path of the
+	// action, when one was asked for.
+	MatchedRoute string `json:"matched_route,omitempty"`
+}
+
+type BatchAuthorizationCheckResult struct {
+	AuthorizationCheckResponse
+
+	Index int `json:"index"`
+}
+
+type BatchAuthorizationCheckResponse struct {
+	Results []BatchAuthorizationCheckResult `json:"results"`
+}
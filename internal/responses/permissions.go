@@ -0,0 +1,42 @@
+package responses
+
+import (
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+)
+
+type UpdatePermissionResponse struct {
+	models.Permission
+
+	RoutesUpdated int      `json:"routes_updated"`
+	Warnings      []string `json:"warnings"`
+}
+
+type BulkUserPermissionResult struct {
+	Index          int                `json:"index"`
+	Username       string             `json:"username"`
+	PermissionName string             `json:"permission_name"`
+	Action         string             `json:"action"`
+	Success        bool               `json:"success"`
+	Error          *apperror.AppError `json:"error,omitempty"`
+}
+
+type BulkUserPermissionsResponse struct {
+	DryRun  bool                       `json:"dry_run"`
+	Applied bool                       `json:"applied"`
+	Results []BulkUserPermissionResult `json:"results"`
+}
+
+type PermissionHolderResponse struct {
+	ID            string   `json:"id"`
+	Username      string   `json:"username"`
+	Direct        bool     `json:"direct"`
+	InheritedFrom []string `json:"inherited_from"`
+}
+
+type PermissionHoldersResponse struct {
+	Total  int                        `json:"total"`
+	Limit  int                        `json:"limit"`
+	Offset int                        `json:"offset"`
+	Users  []PermissionHolderResponse `json:"users"`
+}
@@ -0,0 +1,17 @@
+package responses
+
+import "go-crud-gin/internal/apperror"
+
+type UserImportResult struct {
+	Row               int                `json:"row"`
+	Username          string             `json:"username"`
+	Success           bool               `json:"success"`
+	TemporaryPassword string             `json:"temporary_password,omitempty"`
+	Error             *apperror.AppError `json:"error,omitempty"`
+}
+
+type UsersImportResponse struct {
+	DryRun  bool               `json:"dry_run"`
+	Applied bool               `json:"applied"`
+	Results []UserImportResult `json:"results"`
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"errors"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AccessRequestsOptions struct {
+	// TTL is how long a request stays pending before it expires.
+	TTL time.Duration
+
+	// SensitivePermissions need SensitiveApprovals distinct approvers instead of one.
+	SensitivePermissions []string
+	SensitiveApprovals   int
+}
+
+func DefaultAccessRequestsOptions() AccessRequestsOptions {
+	return AccessRequestsOptions{
+		TTL: time.Duration(72) * time.Hour,
+		SensitivePermissions: []string{
+			"users_full",
+			"permissions_full",
+			"grant_permission",
+			"revoke_permission",
+		},
+		SensitiveApprovals: 2,
+	}
+}
+
+// AccessRequestsService lookups only see the requests filed by users of
+// organizationID, unless it is AllOrganizations.
+type AccessRequestsService interface {
+	Create(organizationID, userID int, permissionName, justification string) (*models.AccessRequest, error)
+	GetAccessRequestByID(organizationID, id int) *models.AccessRequest
+	GetAccessRequests(organizationID int, status models.AccessRequestStatus) []models.AccessRequest
+	GetAccessRequestsForUser(userID int) []models.AccessRequest
+	Approve(organizationID, id, approverID int) (*models.AccessRequest, error)
+	Deny(organizationID, id, approverID int) (*models.AccessRequest, error)
+	ExpirePendingAccessRequests()
+}
+
+type accessRequestsService struct {
+	BaseService
+
+	options            AccessRequestsOptions
+	permissionsService PermissionsService
+
+	// mutex serializes the reviews, which read a request and then update it.
+	mutex          sync.RWMutex
+	accessRequests repositories.AccessRequestsRepository
+}
+
+// expire marks the copy accessRequest as expired if it is pending past its
+// deadline. Lookups can run at the same time, so the expiration is only stored
+// by ExpirePendingAccessRequests.
+func expire(accessRequest *models.AccessRequest) bool {
+	if accessRequest.Status != models.AccessRequestPending || time.Now().Before(accessRequest.ExpiresAt) {
+		return false
+	}
+
+	expiresAt := accessRequest.ExpiresAt
+	accessRequest.Status = models.AccessRequestExpired
+	accessRequest.ResolvedAt = &expiresAt
+
+	return true
+}
+
+// get returns the request with id, or nil, reporting it as expired when due.
+func (service *accessRequestsService) get(id int) *models.AccessRequest {
+	accessRequest := service.accessRequests.GetByID(id)
+	if accessRequest != nil {
+		expire(accessRequest)
+	}
+
+	return accessRequest
+}
+
+// getAll returns every request, reporting the pending ones past their
+// deadline as expired.
+func (service *accessRequestsService) getAll() []models.AccessRequest {
+	accessRequests := service.accessRequests.GetAll()
+	for i := range accessRequests {
+		expire(&accessRequests[i])
+	}
+
+	return accessRequests
+}
+
+func (service *accessRequestsService) requiredApprovals(permissionName string) int {
+	for _, sensitivePermission := range service.options.SensitivePermissions {
+		if strings.EqualFold(sensitivePermission, permissionName) && service.options.SensitiveApprovals > 1 {
+			return service.options.SensitiveApprovals
+		}
+	}
+
+	return 1
+}
+
+func (service *accessRequestsService) Create(organizationID, userID int, permissionName, justification string) (*models.AccessRequest, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	permission := service.permissionsService.GetPermissionByName(organizationID, permissionName)
+	if permission == nil {
+		return nil, apperror.NewErrPermissionNotFound()
+	}
+
+	if service.permissionsService.UserHasEffectivePermission(userID, permission.ID) {
+		return nil, apperror.NewErrUserAlreadyHasPermission()
+	}
+
+	for _, accessRequest := range service.getAll() {
+		if accessRequest.UserID == userID && accessRequest.PermissionID == permission.ID && accessRequest.Status == models.AccessRequestPending {
+			return nil, apperror.NewErrAccessRequestAlreadyExists()
+		}
+	}
+
+	now := time.Now()
+	accessRequest := models.AccessRequest{
+		OrganizationID:    organizationID,
+		UserID:            userID,
+		PermissionID:      permission.ID,
+		Justification:     justification,
+		Status:            models.AccessRequestPending,
+		RequiredApprovals: service.requiredApprovals(permission.Name),
+		ApprovedBy:        []int{},
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(service.options.TTL),
+	}
+
+	accessRequestID, err := service.accessRequests.Create(accessRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	accessRequest.ID = accessRequestID
+
+	service.logger.Infof("[AccessRequestsService] New access request %d for permission '%s'!", accessRequestID, permission.Name)
+
+	return &accessRequest, nil
+}
+
+func (service *accessRequestsService) GetAccessRequestByID(organizationID, id int) *models.AccessRequest {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	accessRequest := service.get(id)
+	if accessRequest == nil || !inOrganization(organizationID, accessRequest.OrganizationID) {
+		return nil
+	}
+
+	return accessRequest
+}
+
+func (service *accessRequestsService) GetAccessRequests(organizationID int, status models.AccessRequestStatus) []models.AccessRequest {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	accessRequests := []models.AccessRequest{}
+	for _, accessRequest := range service.getAll() {
+		if !inOrganization(organizationID, accessRequest.OrganizationID) || (status != "" && accessRequest.Status != status) {
+			continue
+		}
+
+		accessRequests = append(accessRequests, accessRequest)
+	}
+
+	return accessRequests
+}
+
+func (service *accessRequestsService) GetAccessRequestsForUser(userID int) []models.AccessRequest {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	accessRequests := []models.AccessRequest{}
+	for _, accessRequest := range service.getAll() {
+		if accessRequest.UserID == userID {
+			accessRequests = append(accessRequests, accessRequest)
+		}
+	}
+
+	return accessRequests
+}
+
+// getPendingForReview returns the request that approverID is about to review,
+// rejecting resolved requests and self-reviews.
+func (service *accessRequestsService) getPendingForReview(organizationID, id, approverID int) (*models.AccessRequest, error) {
+	accessRequest := service.get(id)
+	if accessRequest == nil || !inOrganization(organizationID, accessRequest.OrganizationID) {
+		return nil, apperror.NewErrAccessRequestNotFound()
+	}
+
+	if accessRequest.Status != models.AccessRequestPending {
+		return nil, apperror.NewErrAccessRequestNotPending()
+	}
+
+	if accessRequest.UserID == approverID {
+		return nil, apperror.NewErrCannotReviewAccessRequest()
+	}
+
+	return accessRequest, nil
+}
+
+// update stores accessRequest or returns the error of the service for the
+// repository one.
+func (service *accessRequestsService) update(accessRequest models.AccessRequest) error {
+	err := service.accessRequests.Update(accessRequest)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrAccessRequestNotFound()
+	}
+
+	return err
+}
+
+func (service *accessRequestsService) Approve(organizationID, id, approverID int) (*models.AccessRequest, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	accessRequest, err := service.getPendingForReview(organizationID, id, approverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(accessRequest.ApprovedBy, approverID) {
+		return nil, apperror.NewErrAccessRequestAlreadyApproved()
+	}
+
+	permission := service.permissionsService.GetPermissionByID(accessRequest.OrganizationID, accessRequest.PermissionID)
+	if permission == nil {
+		return nil, apperror.NewErrPermissionNotFound()
+	}
+
+	// Every approver must be allowed to grant the permission on their own.
+	err = service.permissionsService.CanGrantPermission(accessRequest.OrganizationID, approverID, permission.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user may have got the permission since the request was filed, and
+	// granting it again would fail on every approval until it expires.
+	if service.permissionsService.UserHasEffectivePermission(accessRequest.UserID, permission.ID) {
+		now := time.Now()
+		accessRequest.Status = models.AccessRequestApproved
+		accessRequest.ResolvedAt = &now
+		accessRequest.ResolutionReason = "El usuario ya tenía el permiso"
+	} else if len(accessRequest.ApprovedBy)+1 >= accessRequest.RequiredApprovals {
+		err := service.permissionsService.GrantPermissionToUser(accessRequest.OrganizationID, approverID, accessRequest.UserID, permission.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		accessRequest.Status = models.AccessRequestApproved
+		accessRequest.ResolvedAt = &now
+	}
+
+	accessRequest.ApprovedBy = append(accessRequest.ApprovedBy, approverID)
+
+	if err := service.update(*accessRequest); err != nil {
+		return nil, err
+	}
+
+	service.logger.Infof("[AccessRequestsService] Access request %d approved by user %d!", id, approverID)
+
+	return accessRequest, nil
+}
+
+func (service *accessRequestsService) Deny(organizationID, id, approverID int) (*models.AccessRequest, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	accessRequest, err := service.getPendingForReview(organizationID, id, approverID)
+	if err != nil {
+		return nil, err
+	}
+
+	permission := service.permissionsService.GetPermissionByID(accessRequest.OrganizationID, accessRequest.PermissionID)
+	if permission == nil {
+		return nil, apperror.NewErrPermissionNotFound()
+	}
+
+	// Only the users who could approve the request can deny it.
+	err = service.permissionsService.CanGrantPermission(accessRequest.OrganizationID, approverID, permission.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	accessRequest.Status = models.AccessRequestDenied
+	accessRequest.DeniedBy = &approverID
+	accessRequest.ResolvedAt = &now
+
+	if err := service.update(*accessRequest); err != nil {
+		return nil, err
+	}
+
+	service.logger.Infof("[AccessRequestsService] Access request %d denied by user %d!", id, approverID)
+
+	return accessRequest, nil
+}
+
+// ExpirePendingAccessRequests stores the expiration of the pending requests
+// past their deadline, which lookups already report as expired. Requests that
+// cannot be updated are left for the next run.
+func (service *accessRequestsService) ExpirePendingAccessRequests() {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	expired := 0
+	for _, accessRequest := range service.accessRequests.GetAll() {
+		if !expire(&accessRequest) {
+			continue
+		}
+
+		if err := service.accessRequests.Update(accessRequest); err != nil {
+			service.logger.Infof("[AccessRequestsService] Access request %d not expired: %v", accessRequest.ID, err)
+			continue
+		}
+
+		expired++
+	}
+
+	if expired > 0 {
+		service.logger.Infof("[AccessRequestsService] %d access requests expired!", expired)
+	}
+}
+
+func NewAccessRequestsService(
+	logger logger.Logger,
+	options AccessRequestsOptions,
+
+	permissionsService PermissionsService,
+
+	accessRequestsRepository repositories.AccessRequestsRepository,
+) AccessRequestsService {
+	return &accessRequestsService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		options:            options,
+		permissionsService: permissionsService,
+
+		accessRequests: accessRequestsRepository,
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/repositories"
+	"testing"
+	"time"
+)
+
+func TestOnlyUsersWhoCanGrantThePermissionDenyRequests(t *testing.T) {
+	services := newTestServices(t)
+	accessRequests := NewAccessRequestsService(testLogger{}, DefaultAccessRequestsOptions(), services.permissions, repositories.NewMemoryStore().AccessRequests())
+
+	grantorID := createTestGrantor(t, services)
+	createTestPermission(t, services, "reports_read")
+	createTestPermission(t, services, "grant_permission")
+
+	requesterID := createTestUser(t, services, "requester")
+	reviewerID := createTestUser(t, services, "reviewer")
+
+	// The reviewer can review requests but cannot grant reports_read.
+	if err := services.permissions.GrantPermissionToUser(DefaultOrganizationID, grantorID, reviewerID, "grant_permission"); err != nil {
+		t.Fatalf("GrantPermissionToUser: %v", err)
+	}
+
+	accessRequest, err := accessRequests.Create(DefaultOrganizationID, requesterID, "reports_read", "reports")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := accessRequests.Deny(DefaultOrganizationID, accessRequest.ID, reviewerID); !hasErrorCode(err, apperror.ErrCannotGrantPermissionCode) {
+		t.Fatalf("Deny by the reviewer: got %v, want %s", err, apperror.ErrCannotGrantPermissionCode)
+	}
+
+	denied, err := accessRequests.Deny(DefaultOrganizationID, accessRequest.ID, grantorID)
+	if err != nil {
+		t.Fatalf("Deny by the grantor: %v", err)
+	}
+
+	if denied.Status != models.AccessRequestDenied {
+		t.Fatalf("got %s, want denied", denied.Status)
+	}
+}
+
+func TestExpiredAccessRequestsAreOnlyStoredByTheExpiration(t *testing.T) {
+	services := newTestServices(t)
+	repository := repositories.NewMemoryStore().AccessRequests()
+
+	options := DefaultAccessRequestsOptions()
+	options.TTL = time.Millisecond
+	accessRequests := NewAccessRequestsService(testLogger{}, options, services.permissions, repository)
+
+	createTestPermission(t, services, "reports_read")
+	requesterID := createTestUser(t, services, "requester")
+
+	accessRequest, err := accessRequests.Create(DefaultOrganizationID, requesterID, "reports_read", "reports")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if got := accessRequests.GetAccessRequestByID(DefaultOrganizationID, accessRequest.ID); got.Status != models.AccessRequestExpired {
+		t.Fatalf("GetAccessRequestByID reports the request as %s, want expired", got.Status)
+	}
+
+	if got := repository.GetByID(accessRequest.ID); got.Status != models.AccessRequestPending {
+		t.Fatalf("a lookup stored the expiration")
+	}
+
+	accessRequests.ExpirePendingAccessRequests()
+
+	if got := repository.GetByID(accessRequest.ID); got.Status != models.AccessRequestExpired || got.ResolvedAt == nil {
+		t.Fatalf("ExpirePendingAccessRequests left the request %s resolved at %v", got.Status, got.ResolvedAt)
+	}
+}
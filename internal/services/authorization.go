@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/authenticator"
+	"go-crud-gin/internal/platform/logger"
+	"slices"
+	"strings"
+)
+
+// AuthorizationService answers whether a user may do something, with the
+// same rules the authenticator applies to the routes of this service, plus
+// optional conditions on the custom attributes of the user.
+type AuthorizationService interface {
+	Check(organizationID int, username string, permissions []string, resource *models.AuthorizationResource, attributes map[string]any) models.AuthorizationDecision
+}
+
+type authorizationService struct {
+	BaseService
+
+	usersService       UsersService
+	permissionsService PermissionsService
+	groupsService      GroupsService
+}
+
+func deny(code models.AuthorizationReasonCode, message string) models.AuthorizationDecision {
+	return models.AuthorizationDecision{
+		Allowed:            false,
+		MatchedPermissions: []string{},
+		Reasons: []models.AuthorizationReason{
+			{
+				Code:    code,
+				Message: message,
+			},
+		},
+	}
+}
+
+func (service *authorizationService) Check(organizationID int, username string, permissions []string, resource *models.AuthorizationResource, attributes map[string]any) models.AuthorizationDecision {
+	user := service.usersService.GetByUsername(organizationID, username)
+	if user == nil {
+		return deny(models.AuthorizationReasonSubjectNotFound, fmt.Sprintf("El usuario %s no existe", username))
+	}
+
+	if user.Status == models.UserSuspended {
+		return deny(models.AuthorizationReasonSubjectSuspended, fmt.Sprintf("El usuario %s está suspendido", username))
+	}
+
+	if resource != nil && resource.OrganizationID != nil && *resource.OrganizationID != user.OrganizationID && !service.permissionsService.IsPlatformAdmin(user.ID) {
+		return deny(models.AuthorizationReasonOrganizationMismatch, "El recurso pertenece a otra organización")
+	}
+
+	attributeNames := []string{}
+	for name := range attributes {
+		attributeNames = append(attributeNames, name)
+	}
+
+	slices.Sort(attributeNames)
+
+	for _, name := range attributeNames {
+		value, ok := user.Attributes[name]
+		if !ok || value != attributes[name] {
+			return deny(models.AuthorizationReasonAttributesMismatch, fmt.Sprintf("El atributo %s del usuario no tiene el valor requerido", name))
+		}
+	}
+
+	reasons := []models.AuthorizationReason{}
+	if len(attributeNames) > 0 {
+		reasons = append(reasons, models.AuthorizationReason{
+			Code:    models.AuthorizationReasonAttributesMatch,
+			Message: fmt.Sprintf("El usuario tiene los atributos requeridos: %s", strings.Join(attributeNames, ", ")),
+		})
+	}
+
+	if len(permissions) == 0 {
+		return models.AuthorizationDecision{
+			Allowed:            true,
+			MatchedPermissions: []string{},
+			Reasons: append(reasons, models.AuthorizationReason{
+				Code:    models.AuthorizationReasonNoPermissionsRequired,
+				Message: "La acción no requiere permisos",
+			}),
+		}
+	}
+
+	if !authenticator.HasAnyPermission(service.permissionsService.GetPermissionNamesForUser(user.ID), permissions) {
+		return deny(models.AuthorizationReasonMissingPermissions, fmt.Sprintf(
+			"El usuario no posee ninguno de los permisos requeridos: %s",
+			strings.Join(permissions, ", "),
+		))
+	}
+
+	decision := models.AuthorizationDecision{
+		Allowed:            true,
+		MatchedPermissions: []string{},
+		Reasons:            reasons,
+	}
+
+	for _, effectivePermission := range service.permissionsService.GetEffectivePermissionsForUser(user.ID) {
+		permission := service.permissionsService.GetPermissionByID(AllOrganizations, effectivePermission.PermissionID)
+		if permission == nil || !slices.Contains(permissions, permission.Name) {
+			continue
+		}
+
+		if !slices.Contains(decision.MatchedPermissions, permission.Name) {
+			decision.MatchedPermissions = append(decision.MatchedPermissions, permission.Name)
+		}
+
+		if effectivePermission.GroupID == nil {
+			decision.Reasons = append(decision.Reasons, models.AuthorizationReason{
+				Code:    models.AuthorizationReasonDirectGrant,
+				Message: fmt.Sprintf("El usuario posee el permiso %s", permission.Name),
+			})
+
+			continue
+		}
+
+		groupName := ""
+		if group := service.groupsService.GetGroupByID(AllOrganizations, *effectivePermission.GroupID); group != nil {
+			groupName = group.Name
+		}
+
+		decision.Reasons = append(decision.Reasons, models.AuthorizationReason{
+			Code:    models.AuthorizationReasonGroupGrant,
+			Message: fmt.Sprintf("El usuario hereda el permiso %s del grupo %s", permission.Name, groupName),
+		})
+	}
+
+	return decision
+}
+
+func NewAuthorizationService(
+	logger logger.Logger,
+
+	usersService UsersService,
+	permissionsService PermissionsService,
+	groupsService GroupsService,
+) AuthorizationService {
+	return &authorizationService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		usersService:       usersService,
+		permissionsService: permissionsService,
+		groupsService:      groupsService,
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"go-crud-gin/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestAuthorizationCheck(t *testing.T) {
+	services := newTestServices(t)
+	grantorID := createTestGrantor(t, services)
+
+	for _, name := range []string{"reports_read", "reports_write", "reports_delete"} {
+		createTestPermission(t, services, name)
+	}
+
+	aliceID := createTestUser(t, services, "alice")
+	bobID := createTestUser(t, services, "bob")
+
+	if err := services.permissions.GrantPermissionToUser(DefaultOrganizationID, grantorID, aliceID, "reports_read"); err != nil {
+		t.Fatalf("GrantPermissionToUser: %v", err)
+	}
+
+	// bob inherits reports_write from the parent of its group.
+	parentID, err := services.groups.Create(DefaultOrganizationID, "reporting", "", nil)
+	if err != nil {
+		t.Fatalf("Create reporting: %v", err)
+	}
+
+	childID, err := services.groups.Create(DefaultOrganizationID, "analysts", "", &parentID)
+	if err != nil {
+		t.Fatalf("Create analysts: %v", err)
+	}
+
+	if err := services.permissions.GrantPermissionToGroup(DefaultOrganizationID, grantorID, parentID, "reports_write"); err != nil {
+		t.Fatalf("GrantPermissionToGroup: %v", err)
+	}
+
+	if err := services.groups.AddMembers(childID, []int{bobID}); err != nil {
+		t.Fatalf("AddMembers: %v", err)
+	}
+
+	otherOrganizationID := DefaultOrganizationID + 1
+
+	tests := []struct {
+		name        string
+		username    string
+		permissions []string
+		resource    *models.AuthorizationResource
+		attributes  map[string]any
+		allowed     bool
+		matched     []string
+		reason      models.AuthorizationReasonCode
+	}{
+		{"direct grant", "alice", []string{"reports_read"}, nil, nil, true, []string{"reports_read"}, models.AuthorizationReasonDirectGrant},
+		{"any of the permissions", "alice", []string{"reports_delete", "reports_read"}, nil, nil, true, []string{"reports_read"}, models.AuthorizationReasonDirectGrant},
+		{"nested group grant", "bob", []string{"reports_write"}, nil, nil, true, []string{"reports_write"}, models.AuthorizationReasonGroupGrant},
+		{"missing permissions", "alice", []string{"reports_write"}, nil, nil, false, []string{}, models.AuthorizationReasonMissingPermissions},
+		{"no permissions required", "bob", []string{}, nil, nil, true, []string{}, models.AuthorizationReasonNoPermissionsRequired},
+		{"unknown user", "nobody", []string{"reports_read"}, nil, nil, false, []string{}, models.AuthorizationReasonSubjectNotFound},
+		{"attributes mismatch", "alice", []string{"reports_read"}, nil, map[string]any{"department": "sales"}, false, []string{}, models.AuthorizationReasonAttributesMismatch},
+		{"other organization", "alice", []string{"reports_read"}, &models.AuthorizationResource{OrganizationID: &otherOrganizationID}, nil, false, []string{}, models.AuthorizationReasonOrganizationMismatch},
+	}
+
+	for _, test := range tests {
+		decision := services.authorization.Check(DefaultOrganizationID, test.username, test.permissions, test.resource, test.attributes)
+
+		if decision.Allowed != test.allowed {
+			t.Errorf("%s: allowed = %v, want %v", test.name, decision.Allowed, test.allowed)
+		}
+
+		if !slices.Equal(decision.MatchedPermissions, test.matched) {
+			t.Errorf("%s: matched %v, want %v", test.name, decision.MatchedPermissions, test.matched)
+		}
+
+		if len(decision.Reasons) == 0 || decision.Reasons[0].Code != test.reason {
+			t.Errorf("%s: reasons %v, want %s", test.name, decision.Reasons, test.reason)
+		}
+	}
+}
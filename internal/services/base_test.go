@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/repositories"
+	"sync"
+	"testing"
+)
+
+// testLogger discards the logs, which would bury the output of the tests.
+type testLogger struct{}
+
+func (testLogger) Debugf(format string, args ...any) {}
+func (testLogger) Infof(format string, args ...any)  {}
+
+type testServices struct {
+	users         UsersService
+	groups        GroupsService
+	permissions   PermissionsService
+	authorization AuthorizationService
+}
+
+func newTestServices(t testing.TB) testServices {
+	t.Helper()
+
+	store := repositories.NewMemoryStore()
+
+	users := NewUsersService(testLogger{}, DefaultUsersOptions(), store.Users())
+	groups := NewGroupsService(testLogger{}, store.Groups())
+	permissions := NewPermissionsService(testLogger{}, DefaultPermissionsOptions(), users, groups, store.Permissions(), store.Grants())
+
+	return testServices{
+		users:         users,
+		groups:        groups,
+		permissions:   permissions,
+		authorization: NewAuthorizationService(testLogger{}, users, permissions, groups),
+	}
+}
+
+// createTestUser skips bcrypt, which would make the tests hammering the
+// services too slow under the race detector.
+func createTestUser(t testing.TB, services testServices, username string) int {
+	t.Helper()
+
+	userID, err := services.users.CreateWithPasswordHash(DefaultOrganizationID, username, "hash")
+	if err != nil {
+		t.Fatalf("Create %s: %v", username, err)
+	}
+
+	return userID
+}
+
+// createTestGrantor creates a superuser, who can grant the permissions the
+// tests create.
+func createTestGrantor(t testing.TB, services testServices) int {
+	t.Helper()
+
+	superuserID, err := services.permissions.CreateBuiltIn("superuser", "Superuser")
+	if err != nil {
+		t.Fatalf("CreateBuiltIn superuser: %v", err)
+	}
+
+	grantorID := createTestUser(t, services, "grantor")
+	if err := services.permissions.AssignPermissionToUser(grantorID, superuserID); err != nil {
+		t.Fatalf("AssignPermissionToUser: %v", err)
+	}
+
+	return grantorID
+}
+
+func createTestPermission(t testing.TB, services testServices, name string) int {
+	t.Helper()
+
+	permissionID, err := services.permissions.Create(GlobalOrganizationID, name, name)
+	if err != nil {
+		t.Fatalf("Create %s: %v", name, err)
+	}
+
+	return permissionID
+}
+
+func hasErrorCode(err error, code string) bool {
+	var appError *apperror.AppError
+	return errors.As(err, &appError) && appError.Code == code
+}
+
+// parallel runs every call at once and waits for all of them.
+func parallel(calls ...func()) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	for _, call := range calls {
+		wg.Add(1)
+		go func(call func()) {
+			defer wg.Done()
+			<-start
+			call()
+		}(call)
+	}
+
+	close(start)
+	wg.Wait()
+}
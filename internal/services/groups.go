@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+	"slices"
+)
+
+// GroupsService lookups only see the groups of organizationID, unless it is
+// AllOrganizations. Group names are unique inside an organization.
+type GroupsService interface {
+	Create(organizationID int, name, description string, parentID *int) (int, error)
+	GetGroupByID(organizationID, id int) *models.Group
+	GetGroupByName(organizationID int, name string) *models.Group
+	GetGroups(organizationID int) []models.Group
+	DeleteGroup(organizationID int, name string) error
+	GetMembers(groupID int) []int
+	GetGroupsForUser(userID int) []models.Group
+	GetEffectiveGroupIDsForUser(userID int) []int
+	GetGroupIDsWithParents(groupID int) []int
+	GetEffectiveMemberIDs(groupID int) []int
+	GetEffectiveMemberIDsExcluding(groupID int, excluded func(groupMember models.GroupMember) bool) []int
+	AddMembers(groupID int, userIDs []int) error
+	RemoveMember(groupID, userID int) error
+	RemoveUserFromGroups(userID int) error
+}
+
+type groupsService struct {
+	BaseService
+
+	groups repositories.GroupsRepository
+}
+
+func (service *groupsService) Create(organizationID int, name, description string, parentID *int) (int, error) {
+	if parentID != nil && service.GetGroupByID(organizationID, *parentID) == nil {
+		return 0, apperror.NewErrGroupNotFound()
+	}
+
+	if service.GetGroupByName(organizationID, name) != nil {
+		return 0, apperror.NewErrGroupAlreadyExists()
+	}
+
+	groupID, err := service.groups.Create(models.Group{
+		OrganizationID: organizationID,
+		Name:           name,
+		Description:    description,
+		ParentID:       parentID,
+	})
+	if errors.Is(err, repositories.ErrAlreadyExists) {
+		return 0, apperror.NewErrGroupAlreadyExists()
+	} else if errors.Is(err, repositories.ErrNotFound) {
+		return 0, apperror.NewErrGroupNotFound()
+	} else if err != nil {
+		return 0, err
+	}
+
+	service.logger.Infof("[GroupsService] New group created %s!", name)
+
+	return groupID, nil
+}
+
+func (service *groupsService) GetGroupByID(organizationID, id int) *models.Group {
+	group := service.groups.GetByID(id)
+	if group == nil || !inOrganization(organizationID, group.OrganizationID) {
+		return nil
+	}
+
+	return group
+}
+
+func (service *groupsService) GetGroupByName(organizationID int, name string) *models.Group {
+	for _, group := range service.groups.GetByName(name) {
+		if inOrganization(organizationID, group.OrganizationID) {
+			return &group
+		}
+	}
+
+	return nil
+}
+
+func (service *groupsService) GetGroups(organizationID int) []models.Group {
+	groups := []models.Group{}
+	for _, group := range service.groups.GetAll() {
+		if inOrganization(organizationID, group.OrganizationID) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// DeleteGroup also removes the memberships of the group.
+func (service *groupsService) DeleteGroup(organizationID int, name string) error {
+	group := service.GetGroupByName(organizationID, name)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	for _, value := range service.groups.GetAll() {
+		if value.ParentID != nil && *value.ParentID == group.ID {
+			return apperror.NewErrGroupHasSubgroups()
+		}
+	}
+
+	err := service.groups.Delete(group.ID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	return err
+}
+
+func (service *groupsService) GetMembers(groupID int) []int {
+	return service.groups.GetMemberIDs(groupID)
+}
+
+func (service *groupsService) GetGroupsForUser(userID int) []models.Group {
+	groups := []models.Group{}
+	for _, groupID := range service.groups.GetGroupIDsForUser(userID) {
+		group := service.GetGroupByID(AllOrganizations, groupID)
+		if group == nil {
+			continue
+		}
+
+		groups = append(groups, *group)
+	}
+
+	return groups
+}
+
+// GetEffectiveGroupIDsForUser returns the groups the user belongs to, directly
+// or because they are members of one of their subgroups.
+func (service *groupsService) GetEffectiveGroupIDsForUser(userID int) []int {
+	visited := map[int]bool{}
+
+	groupIDs := []int{}
+	for _, group := range service.GetGroupsForUser(userID) {
+		current := &group
+		for current != nil && !visited[current.ID] {
+			visited[current.ID] = true
+			groupIDs = append(groupIDs, current.ID)
+
+			if current.ParentID == nil {
+				break
+			}
+
+			current = service.GetGroupByID(AllOrganizations, *current.ParentID)
+		}
+	}
+
+	return groupIDs
+}
+
+// GetGroupIDsWithParents returns the group and all of its parents, whose
+// permissions the members of the group inherit.
+func (service *groupsService) GetGroupIDsWithParents(groupID int) []int {
+	visited := map[int]bool{}
+
+	groupIDs := []int{}
+	current := service.GetGroupByID(AllOrganizations, groupID)
+	for current != nil && !visited[current.ID] {
+		visited[current.ID] = true
+		groupIDs = append(groupIDs, current.ID)
+
+		if current.ParentID == nil {
+			break
+		}
+
+		current = service.GetGroupByID(AllOrganizations, *current.ParentID)
+	}
+
+	return groupIDs
+}
+
+// GetEffectiveMemberIDs returns the members of the group and of all of its
+// subgroups, which inherit its permissions.
+func (service *groupsService) GetEffectiveMemberIDs(groupID int) []int {
+	return service.effectiveMemberIDs(groupID, nil)
+}
+
+// GetEffectiveMemberIDsExcluding works like GetEffectiveMemberIDs as if the
+// memberships matched by excluded had been removed.
+func (service *groupsService) GetEffectiveMemberIDsExcluding(groupID int, excluded func(groupMember models.GroupMember) bool) []int {
+	return service.effectiveMemberIDs(groupID, excluded)
+}
+
+func (service *groupsService) effectiveMemberIDs(groupID int, excluded func(groupMember models.GroupMember) bool) []int {
+	subgroupIDs := map[int][]int{}
+	for _, group := range service.groups.GetAll() {
+		if group.ParentID != nil {
+			subgroupIDs[*group.ParentID] = append(subgroupIDs[*group.ParentID], group.ID)
+		}
+	}
+
+	visited := map[int]bool{}
+	pending := []int{groupID}
+
+	found := map[int]bool{}
+	userIDs := []int{}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+
+		if visited[current] {
+			continue
+		}
+
+		visited[current] = true
+
+		for _, userID := range service.groups.GetMemberIDs(current) {
+			if excluded != nil && excluded(models.GroupMember{GroupID: current, UserID: userID}) {
+				continue
+			}
+
+			if !found[userID] {
+				found[userID] = true
+				userIDs = append(userIDs, userID)
+			}
+		}
+
+		pending = append(pending, subgroupIDs[current]...)
+	}
+
+	return userIDs
+}
+
+func (service *groupsService) AddMembers(groupID int, userIDs []int) error {
+	if service.GetGroupByID(AllOrganizations, groupID) == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	memberIDs := service.groups.GetMemberIDs(groupID)
+	for _, userID := range userIDs {
+		if slices.Contains(memberIDs, userID) {
+			return apperror.NewErrUserAlreadyInGroup()
+		}
+	}
+
+	err := service.groups.AddMembers(groupID, userIDs)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrGroupNotFound()
+	} else if errors.Is(err, repositories.ErrAlreadyExists) {
+		return apperror.NewErrUserAlreadyInGroup()
+	} else if err != nil {
+		return err
+	}
+
+	service.logger.Infof("[GroupsService] %d members added to group %d!", len(userIDs), groupID)
+
+	return nil
+}
+
+func (service *groupsService) RemoveMember(groupID, userID int) error {
+	err := service.groups.RemoveMember(groupID, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrUserNotInGroup()
+	}
+
+	return err
+}
+
+func (service *groupsService) RemoveUserFromGroups(userID int) error {
+	return service.groups.DeleteMemberships(userID)
+}
+
+func NewGroupsService(
+	logger logger.Logger,
+
+	groupsRepository repositories.GroupsRepository,
+) GroupsService {
+	return &groupsService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		groups: groupsRepository,
+	}
+}
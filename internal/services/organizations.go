@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+)
+
+const (
+	// AllOrganizations disables the tenant filter of a lookup. It is only
+	// meant for platform administrators and internal calls.
+	AllOrganizations = -1
+
+	// GlobalOrganizationID owns the permissions shared by every organization.
+	GlobalOrganizationID = 0
+
+	DefaultOrganizationID = 1
+)
+
+// inOrganization reports whether an entity owned by entityOrganizationID is
+// visible from organizationID.
+func inOrganization(organizationID, entityOrganizationID int) bool {
+	return organizationID == AllOrganizations || organizationID == entityOrganizationID
+}
+
+type OrganizationsService interface {
+	Create(name string) (int, error)
+	GetOrganizationByID(id int) *models.Organization
+	GetOrganizationByName(name string) *models.Organization
+	GetOrganizations() []models.Organization
+}
+
+type organizationsService struct {
+	BaseService
+
+	organizations repositories.OrganizationsRepository
+}
+
+func (service *organizationsService) Create(name string) (int, error) {
+	if service.GetOrganizationByName(name) != nil {
+		return 0, apperror.NewErrOrganizationAlreadyExists()
+	}
+
+	organizationID, err := service.organizations.Create(models.Organization{
+		Name: name,
+	})
+	if errors.Is(err, repositories.ErrAlreadyExists) {
+		return 0, apperror.NewErrOrganizationAlreadyExists()
+	} else if err != nil {
+		return 0, err
+	}
+
+	service.logger.Infof("[OrganizationsService] New organization created %s!", name)
+
+	return organizationID, nil
+}
+
+func (service *organizationsService) GetOrganizationByID(id int) *models.Organization {
+	return service.organizations.GetByID(id)
+}
+
+func (service *organizationsService) GetOrganizationByName(name string) *models.Organization {
+	return service.organizations.GetByName(name)
+}
+
+func (service *organizationsService) GetOrganizations() []models.Organization {
+	return service.organizations.GetAll()
+}
+
+// NewOrganizationsService creates the default organization when the
+// repository is empty, so it always gets DefaultOrganizationID.
+func NewOrganizationsService(
+	logger logger.Logger,
+
+	organizationsRepository repositories.OrganizationsRepository,
+) (OrganizationsService, error) {
+	service := &organizationsService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		organizations: organizationsRepository,
+	}
+
+	if service.GetOrganizationByID(DefaultOrganizationID) != nil {
+		return service, nil
+	}
+
+	if len(service.GetOrganizations()) > 0 {
+		return nil, fmt.Errorf("organization %d, the default one, does not exist", DefaultOrganizationID)
+	}
+
+	organizationID, err := service.Create("default")
+	if err != nil {
+		return nil, err
+	}
+
+	if organizationID != DefaultOrganizationID {
+		return nil, fmt.Errorf("default organization created with id %d instead of %d", organizationID, DefaultOrganizationID)
+	}
+
+	return service, nil
+}
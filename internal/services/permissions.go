@@ -1,60 +1,199 @@
 package services
 
 import (
+	"errors"
 	"go-crud-gin/internal/apperror"
 	"go-crud-gin/internal/models"
 	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/pagination"
+	"go-crud-gin/internal/platform/uuid"
+	"go-crud-gin/internal/repositories"
+	"slices"
 	"strings"
+	"time"
 )
 
+// DelegationPrefix marks permissions that let their holders grant another
+// permission without holding it, e.g. "grant:users_read".
+const DelegationPrefix = "grant:"
+
+type PermissionsOptions struct {
+	// PlatformAdminPermission lets its holders act on every organization.
+	PlatformAdminPermission string
+
+	// PlatformPermissions can only be granted by platform administrators.
+	PlatformPermissions []string
+
+	// SuperuserPermission lets its holders grant any permission.
+	SuperuserPermission string
+
+	// SuperuserOnlyPermissions can only be granted by superusers.
+	SuperuserOnlyPermissions []string
+
+	// CriticalPermissions must always keep at least one holder, so revokes
+	// and deletes that would leave them without one are refused.
+	CriticalPermissions []string
+}
+
+func DefaultPermissionsOptions() PermissionsOptions {
+	return PermissionsOptions{
+		PlatformAdminPermission: "platform_admin",
+		PlatformPermissions: []string{
+			"platform_admin",
+		},
+		SuperuserPermission: "superuser",
+		SuperuserOnlyPermissions: []string{
+			"superuser",
+			"permissions_full",
+			"grant_permission",
+			"revoke_permission",
+		},
+		CriticalPermissions: []string{
+			"platform_admin",
+			"permissions_full",
+			"grant_permission",
+			"revoke_permission",
+		},
+	}
+}
+
+// PermissionsService lookups see the global permissions plus the ones of
+// organizationID, or every permission when it is AllOrganizations. Grant
+// operations receive the organization of the user or group being changed.
 type PermissionsService interface {
-	Create(name, description string) (int, error)
-	GetPermissionByID(id int) *models.Permission
-	GetPermissionByName(name string) *models.Permission
-	GetPermissions() []models.Permission
-	DeletePermission(name string) error
+	Create(organizationID int, name, description string) (int, error)
+	CreateBuiltIn(name, description string) (int, error)
+	GetPermissionByID(organizationID, id int) *models.Permission
+	GetPermissionByPublicID(organizationID int, publicID string) *models.Permission
+	GetPermissionByName(organizationID int, name string) *models.Permission
+	GetPermissions(organizationID int) []models.Permission
+	QueryPermissions(organizationID int, query PermissionsQuery) (*pagination.Page[models.Permission], error)
+	UpdatePermission(organizationID int, name string, newName, description *string) (*models.Permission, error)
+	DeletePermission(organizationID int, name string) error
 	GetPermissionsForUser(userID int) []models.UserPermission
+	GetEffectivePermissionsForUser(userID int) []models.EffectivePermission
+	GetPermissionNamesForUser(userID int) []string
+	GetPermissionHolders(permissionID int) []models.PermissionHolder
 	UserHasPermission(userID, permissionID int) bool
-	GrantPermissionToUser(userID int, permissionName string) error
-	RevokePermissionToUser(userID int, permissionName string) error
+	UserHasEffectivePermission(userID, permissionID int) bool
+	IsPlatformAdmin(userID int) bool
+	CanGrantPermission(organizationID, grantorID int, permissionName string) error
+	CanManageUser(managerID, userID int) error
+	CanManageGroup(managerID, groupID int) error
+	GrantPermissionToUser(organizationID, grantorID, userID int, permissionName string) error
+	AssignPermissionToUser(userID, permissionID int) error
+	RevokePermissionToUser(organizationID, userID int, permissionName string) error
+	ApplyUserPermissionOperations(grantorID int, operations []models.UserPermissionOperation, dryRun bool) ([]error, bool)
+	GetPermissionsForGroup(groupID int) []models.GroupPermission
+	GrantPermissionToGroup(organizationID, grantorID, groupID int, permissionName string) error
+	RevokePermissionToGroup(organizationID, groupID int, permissionName string) error
+	DeletePermissionsForGroup(groupID int) error
+	DeletePermissionsForUser(userID int) error
+	CanRemoveUser(userID int) error
+	CanRemoveGroupMember(groupID, userID int) error
+	CanDeleteGroup(groupID int) error
+}
+
+// PermissionsQuery filters permissions whose name contains NameContains.
+// Permissions can be sorted by id, name or created_at.
+type PermissionsQuery struct {
+	pagination.Query
+
+	NameContains string
+}
+
+var permissionsSortFields = map[string]pagination.Field[models.Permission]{
+	// UUIDv7 sort in creation order.
+	"id": {
+		Value:   func(permission models.Permission) string { return permission.PublicID },
+		Compare: pagination.CompareStrings,
+	},
+	"name": {
+		Value:   func(permission models.Permission) string { return permission.Name },
+		Compare: pagination.CompareStrings,
+	},
+	"created_at": {
+		Value:   func(permission models.Permission) string { return pagination.FormatTime(permission.CreatedAt) },
+		Compare: pagination.CompareTimes,
+	},
 }
 
 type permissionsService struct {
 	BaseService
 
-	permissions     []models.Permission
-	userPermissions []models.UserPermission
+	options       PermissionsOptions
+	usersService  UsersService
+	groupsService GroupsService
+
+	permissions repositories.PermissionsRepository
+	grants      repositories.GrantsRepository
 }
 
-func (service *permissionsService) Create(name, description string) (int, error) {
-	lastID := 0
-	for _, permission := range service.permissions {
-		if strings.EqualFold(permission.Name, name) {
-			return 0, apperror.NewErrPermissionAlreadyExists()
-		}
+// isVisible reports whether permission can be seen from organizationID.
+func isVisible(organizationID int, permission models.Permission) bool {
+	return permission.OrganizationID == GlobalOrganizationID || inOrganization(organizationID, permission.OrganizationID)
+}
 
-		if permission.ID > lastID {
-			lastID = permission.ID
-		}
+func (service *permissionsService) Create(organizationID int, name, description string) (int, error) {
+	return service.create(organizationID, name, description, true)
+}
+
+// CreateBuiltIn creates a global permission that cannot be renamed or deleted.
+func (service *permissionsService) CreateBuiltIn(name, description string) (int, error) {
+	return service.create(GlobalOrganizationID, name, description, false)
+}
+
+func (service *permissionsService) create(organizationID int, name, description string, deletable bool) (int, error) {
+	// Global names must stay unique everywhere so they resolve the same way in every organization.
+	if service.nameClashes(organizationID, 0, name) {
+		return 0, apperror.NewErrPermissionAlreadyExists()
 	}
 
-	lastID++
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return 0, err
+	}
 
-	service.permissions = append(service.permissions, models.Permission{
-		ID:          lastID,
-		Name:        name,
-		Description: description,
-		Deletable:   true,
+	permissionID, err := service.permissions.Create(models.Permission{
+		PublicID:       publicID,
+		OrganizationID: organizationID,
+		Name:           name,
+		Description:    description,
+		Deletable:      deletable,
+		CreatedAt:      time.Now(),
 	})
+	if errors.Is(err, repositories.ErrAlreadyExists) {
+		return 0, apperror.NewErrPermissionAlreadyExists()
+	} else if err != nil {
+		return 0, err
+	}
 
 	service.logger.Infof("[PermissionsService] New permission created %s!", name)
 
-	return lastID, nil
+	return permissionID, nil
+}
+
+func (service *permissionsService) GetPermissionByID(organizationID, id int) *models.Permission {
+	permission := service.permissions.GetByID(id)
+	if permission == nil || !isVisible(organizationID, *permission) {
+		return nil
+	}
+
+	return permission
 }
 
-func (service *permissionsService) GetPermissionByID(id int) *models.Permission {
-	for _, permission := range service.permissions {
-		if permission.ID == id {
+func (service *permissionsService) GetPermissionByPublicID(organizationID int, publicID string) *models.Permission {
+	permission := service.permissions.GetByPublicID(publicID)
+	if permission == nil || !isVisible(organizationID, *permission) {
+		return nil
+	}
+
+	return permission
+}
+
+func (service *permissionsService) GetPermissionByName(organizationID int, name string) *models.Permission {
+	for _, permission := range service.permissions.GetByName(name) {
+		if isVisible(organizationID, permission) {
 			return &permission
 		}
 	}
@@ -62,72 +201,226 @@ func (service *permissionsService) GetPermissionByID(id int) *models.Permission
 	return nil
 }
 
-func (service *permissionsService) GetPermissionByName(name string) *models.Permission {
-	for _, permission := range service.permissions {
-		if strings.EqualFold(permission.Name, name) {
-			return &permission
+func (service *permissionsService) GetPermissions(organizationID int) []models.Permission {
+	permissions := []models.Permission{}
+	for _, permission := range service.permissions.GetAll() {
+		if isVisible(organizationID, permission) {
+			permissions = append(permissions, permission)
 		}
 	}
 
-	return nil
+	return permissions
 }
 
-func (service *permissionsService) GetPermissions() []models.Permission {
-	return service.permissions
+func (service *permissionsService) QueryPermissions(organizationID int, query PermissionsQuery) (*pagination.Page[models.Permission], error) {
+	permissions := []models.Permission{}
+	for _, permission := range service.GetPermissions(organizationID) {
+		if strings.Contains(strings.ToLower(permission.Name), strings.ToLower(query.NameContains)) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return pagination.Paginate(permissions, query.Query, permissionsSortFields, func(permission models.Permission) string {
+		return permission.PublicID
+	})
 }
 
-func (service *permissionsService) DeletePermission(name string) error {
-	var permissionIDDeleted *int
+func (service *permissionsService) nameClashes(organizationID, exceptID int, name string) bool {
+	for _, permission := range service.permissions.GetByName(name) {
+		if permission.ID == exceptID {
+			continue
+		}
 
-	newPermissions := []models.Permission{}
-	for _, permission := range service.permissions {
-		if strings.EqualFold(permission.Name, name) {
-			if !permission.Deletable {
-				return apperror.NewErrPermissionNotDeletable()
-			}
+		if permission.OrganizationID == GlobalOrganizationID || organizationID == GlobalOrganizationID || permission.OrganizationID == organizationID {
+			return true
+		}
+	}
 
-			permissionIDDeleted = &permission.ID
+	return false
+}
 
-			continue
+// UpdatePermission keeps the permission ID, so grants survive a rename. The
+// delegation permission of the renamed one, if any, is renamed along with it.
+func (service *permissionsService) UpdatePermission(organizationID int, name string, newName, description *string) (*models.Permission, error) {
+	permission := service.GetPermissionByName(organizationID, name)
+	if permission == nil {
+		return nil, apperror.NewErrPermissionNotFound()
+	}
+
+	if !inOrganization(organizationID, permission.OrganizationID) {
+		return nil, apperror.NewErrPermissionNotEditable()
+	}
+
+	var delegation *models.Permission
+	if newName != nil && !strings.EqualFold(*newName, permission.Name) {
+		if !permission.Deletable {
+			return nil, apperror.NewErrPermissionNotRenamable()
+		}
+
+		if service.nameClashes(permission.OrganizationID, permission.ID, *newName) {
+			return nil, apperror.NewErrPermissionAlreadyExists()
 		}
 
-		newPermissions = append(newPermissions, permission)
+		delegation = service.GetPermissionByName(permission.OrganizationID, DelegationPrefix+permission.Name)
+		if delegation != nil && service.nameClashes(delegation.OrganizationID, delegation.ID, DelegationPrefix+*newName) {
+			return nil, apperror.NewErrPermissionAlreadyExists()
+		}
 	}
 
-	if permissionIDDeleted == nil {
-		return apperror.NewErrPermissionNotFound()
+	if newName != nil {
+		permission.Name = *newName
 	}
 
-	service.permissions = newPermissions
+	if description != nil {
+		permission.Description = *description
+	}
 
-	newUserPermissions := []models.UserPermission{}
-	for _, userPermission := range service.userPermissions {
-		if userPermission.PermissionID == *permissionIDDeleted {
-			continue
+	if err := service.update(*permission); err != nil {
+		return nil, err
+	}
+
+	if delegation != nil {
+		delegation.Name = DelegationPrefix + *newName
+		if err := service.update(*delegation); err != nil {
+			return nil, err
 		}
+	}
+
+	service.logger.Infof("[PermissionsService] Permission %s updated!", name)
+
+	return permission, nil
+}
+
+// update stores permission or returns the error of the service for the
+// repository one.
+func (service *permissionsService) update(permission models.Permission) error {
+	err := service.permissions.Update(permission)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrPermissionNotFound()
+	} else if errors.Is(err, repositories.ErrAlreadyExists) {
+		return apperror.NewErrPermissionAlreadyExists()
+	}
+
+	return err
+}
+
+func (service *permissionsService) DeletePermission(organizationID int, name string) error {
+	permission := service.GetPermissionByName(organizationID, name)
+	if permission == nil {
+		return apperror.NewErrPermissionNotFound()
+	}
 
-		newUserPermissions = append(newUserPermissions, userPermission)
+	// Organizations can see the global permissions but only platform administrators can delete them.
+	if !permission.Deletable || !inOrganization(organizationID, permission.OrganizationID) {
+		return apperror.NewErrPermissionNotDeletable()
 	}
 
-	service.userPermissions = newUserPermissions
+	if service.isCritical(*permission) && len(service.holderIDs(permission.ID, grantsChange{})) > 0 {
+		return apperror.NewErrLastPermissionHolder(permission.Name)
+	}
+
+	// The grants go first, so a failure never leaves grants of a deleted permission.
+	if err := service.grants.DeletePermissionGrants(permission.ID); err != nil {
+		return err
+	}
+
+	if err := service.permissions.Delete(permission.ID); err != nil {
+		return err
+	}
+
+	service.logger.Infof("[PermissionsService] Permission %s deleted!", permission.Name)
 
 	return nil
 }
 
 func (service *permissionsService) GetPermissionsForUser(userID int) []models.UserPermission {
-	permissions := []models.UserPermission{}
-	for _, userPermission := range service.userPermissions {
-		if userPermission.UserID == userID {
-			permissions = append(permissions, userPermission)
+	return service.grants.GetUserGrantsForUser(userID)
+}
+
+// GetEffectivePermissionsForUser merges the grants of the user with the ones
+// of every group they belong to, including the parents of those groups.
+func (service *permissionsService) GetEffectivePermissionsForUser(userID int) []models.EffectivePermission {
+	permissions := []models.EffectivePermission{}
+	for _, userPermission := range service.GetPermissionsForUser(userID) {
+		permissions = append(permissions, models.EffectivePermission{
+			PermissionID: userPermission.PermissionID,
+		})
+	}
+
+	for _, groupID := range service.groupsService.GetEffectiveGroupIDsForUser(userID) {
+		inheritedFrom := groupID
+		for _, groupPermission := range service.GetPermissionsForGroup(groupID) {
+			permissions = append(permissions, models.EffectivePermission{
+				PermissionID: groupPermission.PermissionID,
+				GroupID:      &inheritedFrom,
+			})
 		}
 	}
 
 	return permissions
 }
 
+func (service *permissionsService) GetPermissionNamesForUser(userID int) []string {
+	names := []string{}
+	for _, effectivePermission := range service.GetEffectivePermissionsForUser(userID) {
+		permission := service.GetPermissionByID(AllOrganizations, effectivePermission.PermissionID)
+		if permission == nil || slices.Contains(names, permission.Name) {
+			continue
+		}
+
+		names = append(names, permission.Name)
+	}
+
+	return names
+}
+
+// GetPermissionHolders returns every user that holds the permission, sorted
+// by user ID.
+func (service *permissionsService) GetPermissionHolders(permissionID int) []models.PermissionHolder {
+	holders := map[int]*models.PermissionHolder{}
+	holder := func(userID int) *models.PermissionHolder {
+		if holders[userID] == nil {
+			holders[userID] = &models.PermissionHolder{
+				UserID:   userID,
+				GroupIDs: []int{},
+			}
+		}
+
+		return holders[userID]
+	}
+
+	for _, userPermission := range service.grants.GetUserGrantsForPermission(permissionID) {
+		holder(userPermission.UserID).Direct = true
+	}
+
+	for _, groupPermission := range service.grants.GetGroupGrantsForPermission(permissionID) {
+		for _, userID := range service.groupsService.GetEffectiveMemberIDs(groupPermission.GroupID) {
+			value := holder(userID)
+			value.GroupIDs = append(value.GroupIDs, groupPermission.GroupID)
+		}
+	}
+
+	result := []models.PermissionHolder{}
+	for _, value := range holders {
+		if service.isActiveUser(value.UserID) {
+			result = append(result, *value)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b models.PermissionHolder) int {
+		return a.UserID - b.UserID
+	})
+
+	return result
+}
+
 func (service *permissionsService) UserHasPermission(userID, permissionID int) bool {
-	for _, userPermission := range service.userPermissions {
-		if userPermission.UserID == userID && userPermission.PermissionID == permissionID {
+	return service.grants.HasUserGrant(userID, permissionID)
+}
+
+func (service *permissionsService) UserHasEffectivePermission(userID, permissionID int) bool {
+	for _, effectivePermission := range service.GetEffectivePermissionsForUser(userID) {
+		if effectivePermission.PermissionID == permissionID {
 			return true
 		}
 	}
@@ -135,135 +428,533 @@ func (service *permissionsService) UserHasPermission(userID, permissionID int) b
 	return false
 }
 
-func (service *permissionsService) GrantPermissionToUser(userID int, permissionName string) error {
-	permission := service.GetPermissionByName(permissionName)
+// userHasPermissionNamed checks the effective grants of userID for a permission
+// called name, as seen from organizationID.
+func (service *permissionsService) userHasPermissionNamed(organizationID, userID int, name string) bool {
+	permission := service.GetPermissionByName(organizationID, name)
+	if permission == nil {
+		return false
+	}
+
+	return service.UserHasEffectivePermission(userID, permission.ID)
+}
+
+func (service *permissionsService) IsPlatformAdmin(userID int) bool {
+	return service.userHasPermissionNamed(GlobalOrganizationID, userID, service.options.PlatformAdminPermission)
+}
+
+func (service *permissionsService) CanGrantPermission(organizationID, grantorID int, permissionName string) error {
+	permission := service.GetPermissionByName(organizationID, permissionName)
+	if permission == nil {
+		return apperror.NewErrPermissionNotFound()
+	}
+
+	isPlatformAdmin := service.IsPlatformAdmin(grantorID)
+	for _, platformPermission := range service.options.PlatformPermissions {
+		if strings.EqualFold(platformPermission, permission.Name) && !isPlatformAdmin {
+			return apperror.NewErrPermissionPlatformOnly()
+		}
+	}
+
+	if isPlatformAdmin || service.userHasPermissionNamed(organizationID, grantorID, service.options.SuperuserPermission) {
+		return nil
+	}
+
+	for _, superuserOnlyPermission := range service.options.SuperuserOnlyPermissions {
+		if strings.EqualFold(superuserOnlyPermission, permission.Name) {
+			return apperror.NewErrPermissionSuperuserOnly()
+		}
+	}
+
+	if service.UserHasEffectivePermission(grantorID, permission.ID) || service.userHasPermissionNamed(organizationID, grantorID, DelegationPrefix+permission.Name) {
+		return nil
+	}
+
+	return apperror.NewErrCannotGrantPermission()
+}
+
+// CanManageUser checks that the manager could grant every permission the
+// user holds, directly or through groups, before taking over the user's
+// credentials.
+func (service *permissionsService) CanManageUser(managerID, userID int) error {
+	user := service.usersService.GetByID(AllOrganizations, userID)
+	if user == nil {
+		return apperror.NewErrUserNotFound()
+	}
+
+	for _, name := range service.GetPermissionNamesForUser(userID) {
+		if err := service.CanGrantPermission(user.OrganizationID, managerID, name); err != nil {
+			return apperror.NewErrCannotManageUser()
+		}
+	}
+
+	return nil
+}
+
+// CanManageGroup checks that the manager could grant every permission the
+// group gives its members, including the ones of its parents, before adding
+// members or subgroups to it.
+func (service *permissionsService) CanManageGroup(managerID, groupID int) error {
+	group := service.groupsService.GetGroupByID(AllOrganizations, groupID)
+	if group == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	for _, id := range service.groupsService.GetGroupIDsWithParents(groupID) {
+		for _, groupPermission := range service.GetPermissionsForGroup(id) {
+			permission := service.GetPermissionByID(AllOrganizations, groupPermission.PermissionID)
+			if permission == nil {
+				continue
+			}
+
+			if err := service.CanGrantPermission(group.OrganizationID, managerID, permission.Name); err != nil {
+				return apperror.NewErrCannotManageGroup()
+			}
+		}
+	}
+
+	return nil
+}
+
+func (service *permissionsService) GrantPermissionToUser(organizationID, grantorID, userID int, permissionName string) error {
+	permission := service.GetPermissionByName(organizationID, permissionName)
 	if permission == nil {
 		return apperror.NewErrPermissionNotFound()
 	}
 
+	err := service.CanGrantPermission(organizationID, grantorID, permission.Name)
+	if err != nil {
+		return err
+	}
+
+	// Grants are only kept for existing users, so they are removed along
+	// with the user when it is purged.
+	if service.usersService.GetByID(AllOrganizations, userID) == nil {
+		return apperror.NewErrUserNotFound()
+	}
+
 	hasPermission := service.UserHasPermission(userID, permission.ID)
 	if hasPermission {
 		return apperror.NewErrUserAlreadyHasPermission()
 	}
 
-	service.userPermissions = append(service.userPermissions, models.UserPermission{
+	err = service.grants.AddUserGrant(models.UserPermission{
 		UserID:       userID,
 		PermissionID: permission.ID,
 	})
+	if errors.Is(err, repositories.ErrAlreadyExists) {
+		return apperror.NewErrUserAlreadyHasPermission()
+	} else if err != nil {
+		return err
+	}
 
-	service.logger.Infof("[PermissionsService] Permission '%s' granted to '%s' user!", permissionName, userID)
+	service.logger.Infof("[PermissionsService] Permission '%s' granted to user %d!", permissionName, userID)
 
 	return nil
 }
 
-func (service *permissionsService) RevokePermissionToUser(userID int, permissionName string) error {
-	permission := service.GetPermissionByName(permissionName)
+// AssignPermissionToUser grants the permission without the delegation checks
+// of GrantPermissionToUser, for seeding. It does nothing if the user already
+// has it.
+func (service *permissionsService) AssignPermissionToUser(userID, permissionID int) error {
+	if service.UserHasPermission(userID, permissionID) || service.usersService.GetByID(AllOrganizations, userID) == nil {
+		return nil
+	}
+
+	return service.grants.AddUserGrant(models.UserPermission{
+		UserID:       userID,
+		PermissionID: permissionID,
+	})
+}
+
+func (service *permissionsService) RevokePermissionToUser(organizationID, userID int, permissionName string) error {
+	permission := service.GetPermissionByName(organizationID, permissionName)
 	if permission == nil {
 		return apperror.NewErrPermissionNotFound()
 	}
 
-	newPermissions := []models.UserPermission{}
-	for i := 0; i < len(service.userPermissions); i++ {
-		value := service.userPermissions[i]
-		if value.PermissionID == permission.ID && value.UserID == userID {
+	grant := models.UserPermission{
+		UserID:       userID,
+		PermissionID: permission.ID,
+	}
+
+	if !service.grants.HasUserGrant(userID, permission.ID) {
+		return apperror.NewErrUserPermissionNotFound()
+	}
+
+	err := service.ensureCriticalPermissionsHeld(grantsChange{
+		removedUserGrants: []models.UserPermission{grant},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = service.grants.RemoveUserGrant(grant)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrUserPermissionNotFound()
+	}
+
+	return err
+}
+
+// applyUserPermissionOperation validates operation against held, which tells
+// the grants the previous operations leave, and returns the grant it changes.
+func (service *permissionsService) applyUserPermissionOperation(
+	held func(grant models.UserPermission) bool,
+	grantorID int,
+	operation models.UserPermissionOperation,
+) (models.UserPermission, error) {
+	permission := service.GetPermissionByName(operation.OrganizationID, operation.PermissionName)
+	if permission == nil {
+		return models.UserPermission{}, apperror.NewErrPermissionNotFound()
+	}
+
+	grant := models.UserPermission{
+		UserID:       operation.UserID,
+		PermissionID: permission.ID,
+	}
+
+	switch operation.Action {
+	case models.PermissionActionGrant:
+		err := service.CanGrantPermission(operation.OrganizationID, grantorID, permission.Name)
+		if err != nil {
+			return grant, err
+		}
+
+		if held(grant) {
+			return grant, apperror.NewErrUserAlreadyHasPermission()
+		}
+
+		return grant, nil
+	case models.PermissionActionRevoke:
+		if !held(grant) {
+			return grant, apperror.NewErrUserPermissionNotFound()
+		}
+
+		return grant, nil
+	}
+
+	return grant, apperror.NewErrValidation(map[string]string{
+		"action": "La acción debe ser grant o revoke",
+	})
+}
+
+// ApplyUserPermissionOperations validates every operation against the grants
+// the previous ones would leave, and only applies them when all of them are
+// valid and dryRun is false. The returned errors match operations by index.
+func (service *permissionsService) ApplyUserPermissionOperations(grantorID int, operations []models.UserPermissionOperation, dryRun bool) ([]error, bool) {
+	// pending tells whether the grants changed by the previous operations are
+	// held, and changed keeps them in the order of the operations.
+	pending := map[models.UserPermission]bool{}
+	changed := []models.UserPermission{}
+	held := func(grant models.UserPermission) bool {
+		if isHeld, ok := pending[grant]; ok {
+			return isHeld
+		}
+
+		return service.grants.HasUserGrant(grant.UserID, grant.PermissionID)
+	}
+
+	failed := false
+	errs := make([]error, len(operations))
+	for i, operation := range operations {
+		var grant models.UserPermission
+		grant, errs[i] = service.applyUserPermissionOperation(held, grantorID, operation)
+		if errs[i] != nil {
+			failed = true
+			continue
+		}
+
+		if _, ok := pending[grant]; !ok {
+			changed = append(changed, grant)
+		}
+
+		pending[grant] = operation.Action == models.PermissionActionGrant
+	}
+
+	change := grantsChange{
+		addedUserGrants:   []models.UserPermission{},
+		removedUserGrants: []models.UserPermission{},
+	}
+	for _, grant := range changed {
+		if pending[grant] == service.grants.HasUserGrant(grant.UserID, grant.PermissionID) {
 			continue
 		}
 
-		newPermissions = append(newPermissions, value)
+		if pending[grant] {
+			change.addedUserGrants = append(change.addedUserGrants, grant)
+		} else {
+			change.removedUserGrants = append(change.removedUserGrants, grant)
+		}
 	}
 
-	if len(service.userPermissions) == len(newPermissions) {
-		return apperror.NewErrUserPermissionNotFound()
+	if !failed {
+		failed = service.checkCriticalPermissionOperations(change, operations, errs)
+	}
+
+	if failed || dryRun {
+		return errs, false
+	}
+
+	// Nothing was applied, so every operation failed.
+	if err := service.grants.ApplyUserGrants(change.addedUserGrants, change.removedUserGrants); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return errs, false
+	}
+
+	service.logger.Infof("[PermissionsService] %d permission operations applied!", len(operations))
+
+	return errs, true
+}
+
+func (service *permissionsService) GetPermissionsForGroup(groupID int) []models.GroupPermission {
+	return service.grants.GetGroupGrantsForGroup(groupID)
+}
+
+func (service *permissionsService) GrantPermissionToGroup(organizationID, grantorID, groupID int, permissionName string) error {
+	permission := service.GetPermissionByName(organizationID, permissionName)
+	if permission == nil {
+		return apperror.NewErrPermissionNotFound()
+	}
+
+	err := service.CanGrantPermission(organizationID, grantorID, permission.Name)
+	if err != nil {
+		return err
+	}
+
+	if service.groupsService.GetGroupByID(organizationID, groupID) == nil {
+		return apperror.NewErrGroupNotFound()
+	}
+
+	err = service.grants.AddGroupGrant(models.GroupPermission{
+		GroupID:      groupID,
+		PermissionID: permission.ID,
+	})
+	if errors.Is(err, repositories.ErrAlreadyExists) {
+		return apperror.NewErrGroupAlreadyHasPermission()
+	} else if err != nil {
+		return err
+	}
+
+	service.logger.Infof("[PermissionsService] Permission '%s' granted to group %d!", permissionName, groupID)
+
+	return nil
+}
+
+func (service *permissionsService) RevokePermissionToGroup(organizationID, groupID int, permissionName string) error {
+	permission := service.GetPermissionByName(organizationID, permissionName)
+	if permission == nil {
+		return apperror.NewErrPermissionNotFound()
+	}
+
+	if service.groupsService.GetGroupByID(organizationID, groupID) == nil {
+		return apperror.NewErrGroupNotFound()
 	}
 
-	service.userPermissions = newPermissions
+	grant := models.GroupPermission{
+		GroupID:      groupID,
+		PermissionID: permission.ID,
+	}
+
+	if !service.grants.HasGroupGrant(groupID, permission.ID) {
+		return apperror.NewErrGroupPermissionNotFound()
+	}
+
+	err := service.ensureCriticalPermissionsHeld(grantsChange{
+		removedGroupGrant: func(groupPermission models.GroupPermission) bool {
+			return groupPermission == grant
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = service.grants.RemoveGroupGrant(grant)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrGroupPermissionNotFound()
+	}
+
+	return err
+}
+
+func (service *permissionsService) DeletePermissionsForGroup(groupID int) error {
+	return service.grants.DeleteGroupGrants(groupID)
+}
+
+func (service *permissionsService) DeletePermissionsForUser(userID int) error {
+	return service.grants.DeleteUserGrants(userID)
+}
+
+func (service *permissionsService) isCritical(permission models.Permission) bool {
+	return permission.OrganizationID == GlobalOrganizationID && slices.ContainsFunc(service.options.CriticalPermissions, func(name string) bool {
+		return strings.EqualFold(name, permission.Name)
+	})
+}
+
+// grantsChange describes a change the critical permissions checks consider
+// before it is applied. The zero value changes nothing.
+type grantsChange struct {
+	addedUserGrants   []models.UserPermission
+	removedUserGrants []models.UserPermission
+
+	// removedGroupGrant matches the group grants the change removes.
+	removedGroupGrant func(groupPermission models.GroupPermission) bool
+
+	// excludedUserID (0 for none) and the memberships matched by
+	// excludedMembership no longer count as holders.
+	excludedUserID     int
+	excludedMembership func(groupMember models.GroupMember) bool
+}
+
+// holderIDs returns the users that would hold the permission after the
+// change. It only reads the grants of the permission.
+func (service *permissionsService) holderIDs(permissionID int, change grantsChange) map[int]bool {
+	holders := map[int]bool{}
+	for _, userPermission := range service.grants.GetUserGrantsForPermission(permissionID) {
+		if !slices.Contains(change.removedUserGrants, userPermission) {
+			holders[userPermission.UserID] = true
+		}
+	}
+
+	for _, userPermission := range change.addedUserGrants {
+		if userPermission.PermissionID == permissionID {
+			holders[userPermission.UserID] = true
+		}
+	}
+
+	for _, groupPermission := range service.grants.GetGroupGrantsForPermission(permissionID) {
+		if change.removedGroupGrant != nil && change.removedGroupGrant(groupPermission) {
+			continue
+		}
+
+		memberIDs := service.groupsService.GetEffectiveMemberIDs(groupPermission.GroupID)
+		if change.excludedMembership != nil {
+			memberIDs = service.groupsService.GetEffectiveMemberIDsExcluding(groupPermission.GroupID, change.excludedMembership)
+		}
+
+		for _, userID := range memberIDs {
+			holders[userID] = true
+		}
+	}
+
+	delete(holders, change.excludedUserID)
+
+	for userID := range holders {
+		if !service.isActiveUser(userID) {
+			delete(holders, userID)
+		}
+	}
+
+	return holders
+}
+
+// isActiveUser reports whether userID can use its grants. Deleted users keep
+// them until they are purged and suspended users until they are reactivated,
+// but they do not hold any permission meanwhile.
+func (service *permissionsService) isActiveUser(userID int) bool {
+	user := service.usersService.GetByID(AllOrganizations, userID)
+
+	return user != nil && user.Status != models.UserSuspended
+}
+
+// unheldCriticalPermission returns the first critical permission that has
+// holders now but would have none after the change, or nil.
+func (service *permissionsService) unheldCriticalPermission(change grantsChange) *models.Permission {
+	for _, name := range service.options.CriticalPermissions {
+		permission := service.GetPermissionByName(GlobalOrganizationID, name)
+		if permission == nil {
+			continue
+		}
+
+		before := service.holderIDs(permission.ID, grantsChange{})
+		after := service.holderIDs(permission.ID, change)
+		if len(before) > 0 && len(after) == 0 {
+			return permission
+		}
+	}
 
 	return nil
 }
 
+func (service *permissionsService) ensureCriticalPermissionsHeld(change grantsChange) error {
+	permission := service.unheldCriticalPermission(change)
+	if permission != nil {
+		return apperror.NewErrLastPermissionHolder(permission.Name)
+	}
+
+	return nil
+}
+
+// checkCriticalPermissionOperations reports the error on every revoke of a
+// critical permission that the batch would leave without holders.
+func (service *permissionsService) checkCriticalPermissionOperations(
+	change grantsChange,
+	operations []models.UserPermissionOperation,
+	errs []error,
+) bool {
+	permission := service.unheldCriticalPermission(change)
+	if permission == nil {
+		return false
+	}
+
+	for i, operation := range operations {
+		if operation.Action == models.PermissionActionRevoke && strings.EqualFold(operation.PermissionName, permission.Name) {
+			errs[i] = apperror.NewErrLastPermissionHolder(permission.Name)
+		}
+	}
+
+	return true
+}
+
+func (service *permissionsService) CanRemoveUser(userID int) error {
+	return service.ensureCriticalPermissionsHeld(grantsChange{
+		excludedUserID: userID,
+	})
+}
+
+func (service *permissionsService) CanRemoveGroupMember(groupID, userID int) error {
+	return service.ensureCriticalPermissionsHeld(grantsChange{
+		excludedMembership: func(groupMember models.GroupMember) bool {
+			return groupMember.GroupID == groupID && groupMember.UserID == userID
+		},
+	})
+}
+
+func (service *permissionsService) CanDeleteGroup(groupID int) error {
+	// Deleting the group also removes its grants and memberships.
+	return service.ensureCriticalPermissionsHeld(grantsChange{
+		removedGroupGrant: func(groupPermission models.GroupPermission) bool {
+			return groupPermission.GroupID == groupID
+		},
+		excludedMembership: func(groupMember models.GroupMember) bool {
+			return groupMember.GroupID == groupID
+		},
+	})
+}
+
 func NewPermissionsService(
 	logger logger.Logger,
+	options PermissionsOptions,
+
+	usersService UsersService,
+	groupsService GroupsService,
+
+	permissionsRepository repositories.PermissionsRepository,
+	grantsRepository repositories.GrantsRepository,
 ) PermissionsService {
 	return &permissionsService{
 		BaseService: BaseService{
 			logger: logger,
 		},
 
-		permissions: []models.Permission{
-			{
-				ID:          1,
-				Name:        "users_full",
-				Description: "Full access to users endpoints",
-				Deletable:   false,
-			},
-			{
-				ID:          2,
-				Name:        "users_read",
-				Description: "Only access to users GET endpoints",
-				Deletable:   false,
-			},
-			{
-				ID:          3,
-				Name:        "users_write",
-				Description: "Only access to users POST, PUT and DELETE endpoints",
-				Deletable:   false,
-			},
-			{
-				ID:          4,
-				Name:        "permissions_full",
-				Description: "Full access to permissions endpoints",
-				Deletable:   false,
-			},
-			{
-				ID:          5,
-				Name:        "permissions_read",
-				Description: "Only access to permissions GET endpoints",
-				Deletable:   false,
-			},
-			{
-				ID:          6,
-				Name:        "permissions_write",
-				Description: "Only access to permissions POST, PUT and DELETE endpoints",
-				Deletable:   false,
-			},
-			{
-				ID:          7,
-				Name:        "grant_permission",
-				Description: "Grant a permission to an user",
-				Deletable:   false,
-			},
-			{
-				ID:          8,
-				Name:        "revoke_permission",
-				Description: "Revoke a permission to an user",
-				Deletable:   false,
-			},
-		},
-		userPermissions: []models.UserPermission{
-			{
-				UserID:       1,
-				PermissionID: 1,
-			},
-			{
-				UserID:       1,
-				PermissionID: 4,
-			},
-			{
-				UserID:       1,
-				PermissionID: 7,
-			},
-			{
-				UserID:       1,
-				PermissionID: 8,
-			},
-			{
-				UserID:       2,
-				PermissionID: 2,
-			},
-			{
-				UserID:       2,
-				PermissionID: 5,
-			},
-		},
+		options:       options,
+		usersService:  usersService,
+		groupsService: groupsService,
+
+		permissions: permissionsRepository,
+		grants:      grantsRepository,
 	}
 }
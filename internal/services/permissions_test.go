@@ -0,0 +1,247 @@
+package services
+
+import (
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentGrantsAreNotDuplicated(t *testing.T) {
+	services := newTestServices(t)
+	grantorID := createTestGrantor(t, services)
+	createTestPermission(t, services, "reports_read")
+
+	const users, attempts = 20, 10
+
+	var mutex sync.Mutex
+	granted := map[int]int{}
+
+	userIDs := []int{}
+	calls := []func(){}
+	for i := 0; i < users; i++ {
+		userID := createTestUser(t, services, fmt.Sprintf("user%d", i))
+		userIDs = append(userIDs, userID)
+
+		for j := 0; j < attempts; j++ {
+			calls = append(calls, func() {
+				err := services.permissions.GrantPermissionToUser(DefaultOrganizationID, grantorID, userID, "reports_read")
+				if err != nil {
+					if !hasErrorCode(err, apperror.ErrUserAlreadyHasPermissionCode) {
+						t.Errorf("GrantPermissionToUser %d: %v", userID, err)
+					}
+					return
+				}
+
+				mutex.Lock()
+				granted[userID]++
+				mutex.Unlock()
+			})
+		}
+	}
+
+	parallel(calls...)
+
+	for _, userID := range userIDs {
+		if got := granted[userID]; got != 1 {
+			t.Errorf("user %d granted %d times, want 1", userID, got)
+		}
+
+		if got := services.permissions.GetPermissionsForUser(userID); len(got) != 1 {
+			t.Errorf("user %d has %d grants, want 1", userID, len(got))
+		}
+	}
+}
+
+func TestConcurrentGrantsAndRevokesAreNotLost(t *testing.T) {
+	services := newTestServices(t)
+	grantorID := createTestGrantor(t, services)
+
+	const users = 20
+	permissions := []string{"reports_read", "reports_write", "invoices_read", "invoices_write", "audit_read"}
+	for _, permission := range permissions {
+		createTestPermission(t, services, permission)
+	}
+
+	userIDs := []int{}
+	calls := []func(){}
+	for i := 0; i < users; i++ {
+		userID := createTestUser(t, services, fmt.Sprintf("user%d", i))
+		userIDs = append(userIDs, userID)
+
+		// Every grant changes on its own, so none of the calls may fail and
+		// every user must end with all the permissions.
+		for _, permission := range permissions {
+			permission := permission
+
+			calls = append(calls, func() {
+				for _, step := range []string{"grant", "revoke", "grant"} {
+					var err error
+					if step == "grant" {
+						err = services.permissions.GrantPermissionToUser(DefaultOrganizationID, grantorID, userID, permission)
+					} else {
+						err = services.permissions.RevokePermissionToUser(DefaultOrganizationID, userID, permission)
+					}
+
+					if err != nil {
+						t.Errorf("%s %s to user %d: %v", step, permission, userID, err)
+					}
+				}
+			})
+		}
+
+		calls = append(calls, func() {
+			services.permissions.GetPermissionNamesForUser(userID)
+			services.permissions.GetEffectivePermissionsForUser(userID)
+		})
+	}
+
+	parallel(calls...)
+
+	for _, userID := range userIDs {
+		if got := services.permissions.GetPermissionsForUser(userID); len(got) != len(permissions) {
+			t.Errorf("user %d has %d grants, want %d", userID, len(got), len(permissions))
+		}
+	}
+}
+
+// benchmarkSizes are the numbers of users the benchmarks run with, each with
+// benchmarkGrantsPerUser direct grants, so the largest one holds 1M grants.
+// Lookups must take the same time at every size.
+var benchmarkSizes = []int{1_000, 10_000, 100_000}
+
+const (
+	benchmarkPermissions   = 100
+	benchmarkGrantsPerUser = 10
+
+	// The groups form benchmarkGroupLevels levels of benchmarkGroupFanOut
+	// subgroups each, every one granted benchmarkGroupGrants permissions, and
+	// every user belongs to one of the deepest groups.
+	benchmarkGroupLevels  = 3
+	benchmarkGroupFanOut  = 10
+	benchmarkGroupGrants  = 2
+	benchmarkEffectiveLen = benchmarkGrantsPerUser + benchmarkGroupLevels*benchmarkGroupGrants
+)
+
+type benchmarkFixture struct {
+	services testServices
+	userIDs  []int
+
+	// inheritedPermissionID is only granted to the root groups, so checking
+	// it walks every level of groups.
+	inheritedPermissionID int
+}
+
+// benchmarkFixtures caches the fixtures by number of users, since the
+// benchmarks run several times and filling the largest ones takes a while.
+var benchmarkFixtures = map[int]benchmarkFixture{}
+
+func newBenchmarkFixture(b *testing.B, users int) benchmarkFixture {
+	b.Helper()
+
+	if fixture, ok := benchmarkFixtures[users]; ok {
+		return fixture
+	}
+
+	services := newTestServices(b)
+	grantorID := createTestGrantor(b, services)
+
+	permissionIDs := make([]int, benchmarkPermissions)
+	for i := range permissionIDs {
+		permissionIDs[i] = createTestPermission(b, services, fmt.Sprintf("permission_%d", i))
+	}
+
+	inheritedPermissionID := createTestPermission(b, services, "inherited")
+	groupPermission := func(level, i int) string {
+		if level == 0 && i == 0 {
+			return "inherited"
+		}
+
+		return fmt.Sprintf("permission_%d", (level*benchmarkGroupGrants+i)%benchmarkPermissions)
+	}
+
+	parentIDs := []*int{nil}
+	for level := 0; level < benchmarkGroupLevels; level++ {
+		groupIDs := []*int{}
+		for _, parentID := range parentIDs {
+			for i := 0; i < benchmarkGroupFanOut; i++ {
+				groupID, err := services.groups.Create(DefaultOrganizationID, fmt.Sprintf("group_%d_%d", level, len(groupIDs)), "", parentID)
+				if err != nil {
+					b.Fatalf("Create group: %v", err)
+				}
+
+				for j := 0; j < benchmarkGroupGrants; j++ {
+					if err := services.permissions.GrantPermissionToGroup(DefaultOrganizationID, grantorID, groupID, groupPermission(level, j)); err != nil {
+						b.Fatalf("GrantPermissionToGroup: %v", err)
+					}
+				}
+
+				groupIDs = append(groupIDs, &groupID)
+			}
+		}
+
+		parentIDs = groupIDs
+	}
+
+	userIDs := make([]int, users)
+	members := map[int][]int{}
+	for i := range userIDs {
+		userIDs[i] = createTestUser(b, services, fmt.Sprintf("user%d", i))
+
+		for j := 0; j < benchmarkGrantsPerUser; j++ {
+			if err := services.permissions.AssignPermissionToUser(userIDs[i], permissionIDs[(i+j*7)%benchmarkPermissions]); err != nil {
+				b.Fatalf("AssignPermissionToUser: %v", err)
+			}
+		}
+
+		groupID := *parentIDs[i%len(parentIDs)]
+		members[groupID] = append(members[groupID], userIDs[i])
+	}
+
+	for groupID, memberIDs := range members {
+		if err := services.groups.AddMembers(groupID, memberIDs); err != nil {
+			b.Fatalf("AddMembers: %v", err)
+		}
+	}
+
+	fixture := benchmarkFixture{
+		services:              services,
+		userIDs:               userIDs,
+		inheritedPermissionID: inheritedPermissionID,
+	}
+
+	benchmarkFixtures[users] = fixture
+
+	return fixture
+}
+
+func BenchmarkGetEffectivePermissionsForUser(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("users=%d", size), func(b *testing.B) {
+			fixture := newBenchmarkFixture(b, size)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				got := fixture.services.permissions.GetEffectivePermissionsForUser(fixture.userIDs[i%size])
+				if len(got) != benchmarkEffectiveLen {
+					b.Fatalf("got %d effective permissions, want %d", len(got), benchmarkEffectiveLen)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUserHasEffectivePermission(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("users=%d", size), func(b *testing.B) {
+			fixture := newBenchmarkFixture(b, size)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if !fixture.services.permissions.UserHasEffectivePermission(fixture.userIDs[i%size], fixture.inheritedPermissionID) {
+					b.Fatalf("user %d does not inherit the permission", fixture.userIDs[i%size])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SeedOptions struct {
+	// Path is the seed file. When it does not exist the copy embedded in the
+	// binary is used.
+	Path string
+}
+
+func DefaultSeedOptions() SeedOptions {
+	return SeedOptions{
+		Path: "config/seed.json",
+	}
+}
+
+// seededSetting is set once the seed users and grants were all created.
+const seededSetting = "seeded"
+
+// SeedService creates the permissions the seed describes and are missing, so
+// applying the same seed again changes nothing. The users and grants are
+// applied until a run creates all of them, which is recorded in the store, so
+// a run interrupted halfway is completed by the next one while the users and
+// grants that operators delete, rename or revoke afterwards stay that way.
+type SeedService interface {
+	Apply(seed models.Seed) error
+}
+
+type seedService struct {
+	BaseService
+
+	organizationsService OrganizationsService
+	usersService         UsersService
+	permissionsService   PermissionsService
+
+	settings repositories.SettingsRepository
+}
+
+func generatePassword() (string, error) {
+	bytes := make([]byte, 18)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func (service *seedService) applyPermissions(permissions []models.SeedPermission) error {
+	for _, permission := range permissions {
+		if permission.Name == "" {
+			return fmt.Errorf("seed permission without permission_name")
+		}
+
+		if service.permissionsService.GetPermissionByName(GlobalOrganizationID, permission.Name) != nil {
+			continue
+		}
+
+		var err error
+		if permission.BuiltIn {
+			_, err = service.permissionsService.CreateBuiltIn(permission.Name, permission.Description)
+		} else {
+			_, err = service.permissionsService.Create(GlobalOrganizationID, permission.Name, permission.Description)
+		}
+
+		if err != nil {
+			return fmt.Errorf("seed permission %s: %w", permission.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (service *seedService) applyUsers(users []models.SeedUser) error {
+	for _, user := range users {
+		if user.Username == "" {
+			return fmt.Errorf("seed user without username")
+		}
+
+		if service.usersService.IsUsernameTaken(user.Username) {
+			continue
+		}
+
+		organizationID := DefaultOrganizationID
+		if user.OrganizationName != "" {
+			organization := service.organizationsService.GetOrganizationByName(user.OrganizationName)
+			if organization == nil {
+				return fmt.Errorf("seed user %s: organization %s does not exist", user.Username, user.OrganizationName)
+			}
+
+			organizationID = organization.ID
+		}
+
+		switch {
+		case user.PasswordHash != "":
+			if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+				return fmt.Errorf("seed user %s: password_hash is not a bcrypt hash: %w", user.Username, err)
+			}
+
+			if _, err := service.usersService.CreateWithPasswordHash(organizationID, user.Username, user.PasswordHash); err != nil {
+				return fmt.Errorf("seed user %s: %w", user.Username, err)
+			}
+		case user.GeneratePassword:
+			password, err := generatePassword()
+			if err != nil {
+				return err
+			}
+
+			if _, err := service.usersService.Create(organizationID, user.Username, password); err != nil {
+				return fmt.Errorf("seed user %s: %w", user.Username, err)
+			}
+
+			service.logger.Infof("[SeedService] Generated password for user %s: %s (it will not be shown again)", user.Username, password)
+		default:
+			return fmt.Errorf("seed user %s: needs a password_hash or generate_password", user.Username)
+		}
+	}
+
+	return nil
+}
+
+func (service *seedService) applyGrants(grants []models.SeedGrant) error {
+	for _, grant := range grants {
+		user := service.usersService.GetByUsername(AllOrganizations, grant.Username)
+		if user == nil {
+			return fmt.Errorf("seed grant: user %s does not exist", grant.Username)
+		}
+
+		permission := service.permissionsService.GetPermissionByName(user.OrganizationID, grant.PermissionName)
+		if permission == nil {
+			return fmt.Errorf("seed grant: permission %s does not exist", grant.PermissionName)
+		}
+
+		// The grants applied by an interrupted run are applied again.
+		err := service.permissionsService.AssignPermissionToUser(user.ID, permission.ID)
+		if err != nil && !errors.Is(err, repositories.ErrAlreadyExists) {
+			return fmt.Errorf("seed grant: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (service *seedService) Apply(seed models.Seed) error {
+	if err := service.applyPermissions(seed.Permissions); err != nil {
+		return err
+	}
+
+	if _, seeded := service.settings.Get(seededSetting); seeded {
+		service.logger.Infof("[SeedService] Seed users and grants already applied, skipping them")
+	} else {
+		if err := service.applyUsers(seed.Users); err != nil {
+			return err
+		}
+
+		if err := service.applyGrants(seed.Grants); err != nil {
+			return err
+		}
+
+		if err := service.settings.Set(seededSetting, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+
+	service.logger.Infof("[SeedService] Seed applied!")
+
+	return nil
+}
+
+func NewSeedService(
+	logger logger.Logger,
+
+	organizationsService OrganizationsService,
+	usersService UsersService,
+	permissionsService PermissionsService,
+
+	settingsRepository repositories.SettingsRepository,
+) SeedService {
+	return &seedService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		organizationsService: organizationsService,
+		usersService:         usersService,
+		permissionsService:   permissionsService,
+
+		settings: settingsRepository,
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/repositories"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSeedCompletesAnInterruptedRunAndKeepsLaterRevocations(t *testing.T) {
+	store := repositories.NewMemoryStore()
+
+	organizations, err := NewOrganizationsService(testLogger{}, store.Organizations())
+	if err != nil {
+		t.Fatalf("NewOrganizationsService: %v", err)
+	}
+
+	users := NewUsersService(testLogger{}, DefaultUsersOptions(), store.Users())
+	groups := NewGroupsService(testLogger{}, store.Groups())
+	permissions := NewPermissionsService(testLogger{}, DefaultPermissionsOptions(), users, groups, store.Permissions(), store.Grants())
+	seed := NewSeedService(testLogger{}, organizations, users, permissions, store.Settings())
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+
+	complete := models.Seed{
+		Permissions: []models.SeedPermission{
+			{Name: "users_full", Description: "users_full"},
+			{Name: "reports_read", Description: "reports_read"},
+		},
+		Users: []models.SeedUser{
+			{Username: "admin", PasswordHash: string(hash)},
+		},
+		Grants: []models.SeedGrant{
+			{Username: "admin", PermissionName: "users_full"},
+			{Username: "admin", PermissionName: "reports_read"},
+		},
+	}
+
+	// The first run stops after creating the user and its first grant.
+	interrupted := complete
+	interrupted.Grants = append([]models.SeedGrant{complete.Grants[0]}, models.SeedGrant{Username: "admin", PermissionName: "missing"})
+
+	if err := seed.Apply(interrupted); err == nil {
+		t.Fatalf("Apply with a missing permission succeeded")
+	}
+
+	if err := seed.Apply(complete); err != nil {
+		t.Fatalf("Apply after the interrupted run: %v", err)
+	}
+
+	admin := users.GetByUsername(AllOrganizations, "admin")
+	if admin == nil {
+		t.Fatalf("admin was not created")
+	}
+
+	for _, name := range []string{"users_full", "reports_read"} {
+		permission := permissions.GetPermissionByName(GlobalOrganizationID, name)
+		if !store.Grants().HasUserGrant(admin.ID, permission.ID) {
+			t.Fatalf("admin lacks %s after the seed was completed", name)
+		}
+	}
+
+	reportsRead := permissions.GetPermissionByName(GlobalOrganizationID, "reports_read")
+	if err := store.Grants().RemoveUserGrant(models.UserPermission{UserID: admin.ID, PermissionID: reportsRead.ID}); err != nil {
+		t.Fatalf("RemoveUserGrant: %v", err)
+	}
+
+	if err := seed.Apply(complete); err != nil {
+		t.Fatalf("Apply after the revocation: %v", err)
+	}
+
+	if store.Grants().HasUserGrant(admin.ID, reportsRead.ID) {
+		t.Fatalf("the revoked grant was applied again")
+	}
+}
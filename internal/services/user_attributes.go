@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/repositories"
+	"slices"
+	"strings"
+)
+
+// UserAttributesService keeps the schema of the custom attributes of users.
+// The users of an organization can have the global attributes and those of
+// their organization, like permissions.
+type UserAttributesService interface {
+	SetDefinition(definition models.UserAttributeDefinition) (*models.UserAttributeDefinition, error)
+	GetDefinition(organizationID int, name string) *models.UserAttributeDefinition
+	GetDefinitions(organizationID int) []models.UserAttributeDefinition
+	DeleteDefinition(organizationID int, name string) (*models.UserAttributeDefinition, error)
+	Validate(organizationID int, attributes map[string]any) map[string]string
+}
+
+type userAttributesService struct {
+	BaseService
+
+	definitions repositories.UserAttributesRepository
+}
+
+// isVisibleDefinition reports whether definition applies to the users of organizationID.
+func isVisibleDefinition(organizationID int, definition models.UserAttributeDefinition) bool {
+	return definition.OrganizationID == GlobalOrganizationID || inOrganization(organizationID, definition.OrganizationID)
+}
+
+// acceptsValue reports whether value has the type of definition. Numbers
+// decoded from JSON are float64.
+func acceptsValue(definition models.UserAttributeDefinition, value any) bool {
+	switch definition.Type {
+	case models.UserAttributeString:
+		_, ok := value.(string)
+		return ok
+	case models.UserAttributeNumber:
+		switch value.(type) {
+		case float64, int:
+			return true
+		}
+	case models.UserAttributeBoolean:
+		_, ok := value.(bool)
+		return ok
+	}
+
+	return false
+}
+
+// SetDefinition creates the definition or replaces the one with the same name
+// in its organization. Users keep their current values until they change
+// their attributes again.
+func (service *userAttributesService) SetDefinition(definition models.UserAttributeDefinition) (*models.UserAttributeDefinition, error) {
+	validationErrors := map[string]string{}
+	for i, value := range definition.AllowedValues {
+		if !acceptsValue(definition, value) {
+			validationErrors[fmt.Sprintf("allowed_values[%d]", i)] = fmt.Sprintf("El valor debe ser de tipo %s", definition.Type)
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return nil, apperror.NewErrValidation(validationErrors)
+	}
+
+	if definition.AllowedValues == nil {
+		definition.AllowedValues = []any{}
+	}
+
+	replaced := false
+	for _, current := range service.definitions.GetAll() {
+		if !strings.EqualFold(current.Name, definition.Name) {
+			continue
+		}
+
+		if current.OrganizationID == definition.OrganizationID {
+			replaced = true
+			continue
+		}
+
+		if current.OrganizationID == GlobalOrganizationID || definition.OrganizationID == GlobalOrganizationID {
+			return nil, apperror.NewErrUserAttributeAlreadyExists()
+		}
+	}
+
+	if err := service.definitions.Save(definition); err != nil {
+		return nil, err
+	}
+
+	if replaced {
+		service.logger.Infof("[UserAttributesService] User attribute %s updated!", definition.Name)
+	} else {
+		service.logger.Infof("[UserAttributesService] New user attribute created %s!", definition.Name)
+	}
+
+	return &definition, nil
+}
+
+func (service *userAttributesService) GetDefinition(organizationID int, name string) *models.UserAttributeDefinition {
+	for _, definition := range service.definitions.GetAll() {
+		if strings.EqualFold(definition.Name, name) && isVisibleDefinition(organizationID, definition) {
+			return &definition
+		}
+	}
+
+	return nil
+}
+
+func (service *userAttributesService) GetDefinitions(organizationID int) []models.UserAttributeDefinition {
+	definitions := []models.UserAttributeDefinition{}
+	for _, definition := range service.definitions.GetAll() {
+		if isVisibleDefinition(organizationID, definition) {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	return definitions
+}
+
+// DeleteDefinition only deletes the definitions owned by organizationID, so
+// organizations cannot delete the global ones.
+func (service *userAttributesService) DeleteDefinition(organizationID int, name string) (*models.UserAttributeDefinition, error) {
+	for _, definition := range service.definitions.GetAll() {
+		if !strings.EqualFold(definition.Name, name) || !isVisibleDefinition(organizationID, definition) {
+			continue
+		}
+
+		if definition.OrganizationID == GlobalOrganizationID && organizationID != AllOrganizations {
+			return nil, apperror.NewErrUserAttributeNotFound()
+		}
+
+		err := service.definitions.Delete(definition.OrganizationID, definition.Name)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, apperror.NewErrUserAttributeNotFound()
+		} else if err != nil {
+			return nil, err
+		}
+
+		service.logger.Infof("[UserAttributesService] User attribute %s deleted!", definition.Name)
+
+		return &definition, nil
+	}
+
+	return nil, apperror.NewErrUserAttributeNotFound()
+}
+
+// Validate checks the attributes of an user of organizationID against the
+// schema and returns the validation errors keyed by attributes.<name>.
+func (service *userAttributesService) Validate(organizationID int, attributes map[string]any) map[string]string {
+	validationErrors := map[string]string{}
+	for name, value := range attributes {
+		definition := service.GetDefinition(organizationID, name)
+		if definition == nil || definition.Name != name {
+			validationErrors["attributes."+name] = "El atributo no está definido"
+			continue
+		}
+
+		if !acceptsValue(*definition, value) {
+			validationErrors["attributes."+name] = fmt.Sprintf("El atributo debe ser de tipo %s", definition.Type)
+			continue
+		}
+
+		if len(definition.AllowedValues) > 0 && !slices.Contains(definition.AllowedValues, value) {
+			validationErrors["attributes."+name] = "El valor no está entre los permitidos"
+		}
+	}
+
+	for _, definition := range service.GetDefinitions(organizationID) {
+		if _, ok := attributes[definition.Name]; definition.Required && !ok {
+			validationErrors["attributes."+definition.Name] = "El atributo es obligatorio"
+		}
+	}
+
+	return validationErrors
+}
+
+func NewUserAttributesService(
+	logger logger.Logger,
+
+	userAttributesRepository repositories.UserAttributesRepository,
+) UserAttributesService {
+	return &userAttributesService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		definitions: userAttributesRepository,
+	}
+}
@@ -1,111 +1,465 @@
 package services
 
 import (
+	"errors"
 	"go-crud-gin/internal/apperror"
 	"go-crud-gin/internal/models"
 	"go-crud-gin/internal/platform/logger"
+	"go-crud-gin/internal/platform/pagination"
+	"go-crud-gin/internal/platform/uuid"
+	"go-crud-gin/internal/repositories"
+	"maps"
+	"slices"
 	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
+type UsersOptions struct {
+	// RetentionPeriod is how long a deleted user can be restored before it
+	// is purged, checked every PurgeInterval.
+	RetentionPeriod time.Duration
+	PurgeInterval   time.Duration
+}
+
+func DefaultUsersOptions() UsersOptions {
+	return UsersOptions{
+		RetentionPeriod: time.Duration(30*24) * time.Hour,
+		PurgeInterval:   time.Duration(1) * time.Hour,
+	}
+}
+
+// UsersService lookups only see the users of organizationID, unless it is
+// AllOrganizations, and never see deleted users. Usernames and emails are
+// unique across every organization, including deleted users that can still
+// be restored, and passwords are only kept as bcrypt hashes.
 type UsersService interface {
-	Create(username, password string) (int, error)
-	GetByID(id int) *models.User
-	GetByUsername(username string) *models.User
-	GetUsers() []models.User
-	DeleteUser(username string) error
+	Create(organizationID int, username, password string) (int, error)
+	CreateWithPasswordHash(organizationID int, username, passwordHash string) (int, error)
+	CheckPassword(user models.User, password string) bool
+	GetByID(organizationID, id int) *models.User
+	GetByPublicID(organizationID int, publicID string) *models.User
+	GetByUsername(organizationID int, username string) *models.User
+	GetUsers(organizationID int) []models.User
+	IsUsernameTaken(username string) bool
+	HasUsers() bool
+	IsEmailTaken(email string) bool
+	QueryUsers(organizationID int, query UsersQuery) (*pagination.Page[models.User], error)
+	UpdateUser(organizationID int, username string, update models.UserUpdate) (*models.User, error)
+	RemoveAttribute(organizationID int, name string) error
+	SuspendUser(organizationID int, username, reason string, until *time.Time) (*models.User, error)
+	ReactivateUser(organizationID int, username string) (*models.User, error)
+	DeleteUser(organizationID int, username string) error
+	RestoreUser(organizationID int, username string) (*models.User, error)
+	PurgeDeletedUsers() []int
+	ReactivateExpiredSuspensions()
+}
+
+// UsersQuery filters users by UsernamePrefix and, when it is not nil, by
+// UserIDs. Users can be sorted by id, name or created_at.
+type UsersQuery struct {
+	pagination.Query
+
+	UsernamePrefix string
+	UserIDs        []int
+}
+
+var usersSortFields = map[string]pagination.Field[models.User]{
+	// UUIDv7 sort in creation order.
+	"id": {
+		Value:   func(user models.User) string { return user.PublicID },
+		Compare: pagination.CompareStrings,
+	},
+	"name": {
+		Value:   func(user models.User) string { return user.Username },
+		Compare: pagination.CompareStrings,
+	},
+	"created_at": {
+		Value:   func(user models.User) string { return pagination.FormatTime(user.CreatedAt) },
+		Compare: pagination.CompareTimes,
+	},
 }
 
 type usersService struct {
 	BaseService
-	users []models.User
+
+	options UsersOptions
+	users   repositories.UsersRepository
 }
 
-func (service *usersService) Create(username, password string) (int, error) {
-	lastID := 0
-	for _, user := range service.users {
-		if strings.EqualFold(user.Username, username) {
-			return 0, apperror.NewErrUserAlreadyExists()
-		}
+// visible reports whether user can be seen from organizationID, when it is
+// deleted or not, reporting it as active if its suspension has ended.
+func (service *usersService) visible(organizationID int, user *models.User, deleted bool) bool {
+	if user == nil || !inOrganization(organizationID, user.OrganizationID) || (user.DeletedAt != nil) != deleted {
+		return false
+	}
 
-		if user.ID > lastID {
-			lastID = user.ID
-		}
+	// Lookups can run at the same time, so the reactivation is only stored
+	// by ReactivateExpiredSuspensions.
+	if suspensionExpired(*user) {
+		reactivate(user)
 	}
 
-	lastID++
+	return true
+}
+
+func suspensionExpired(user models.User) bool {
+	return user.Status == models.UserSuspended && user.SuspendedUntil != nil && !time.Now().Before(*user.SuspendedUntil)
+}
+
+func reactivate(user *models.User) {
+	user.Status = models.UserActive
+	user.SuspensionReason = ""
+	user.SuspendedUntil = nil
+}
+
+// find returns the user called username in organizationID that is deleted or
+// not, or nil.
+func (service *usersService) find(organizationID int, username string, deleted bool) *models.User {
+	user := service.users.GetByUsername(username)
+	if !service.visible(organizationID, user, deleted) {
+		return nil
+	}
+
+	return user
+}
+
+// update stores user or returns the error of the service for the repository one.
+func (service *usersService) update(user *models.User) error {
+	err := service.users.Update(*user)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return apperror.NewErrUserNotFound()
+	} else if errors.Is(err, repositories.ErrAlreadyExists) {
+		return apperror.NewErrUserAlreadyExists()
+	}
+
+	return err
+}
+
+func (service *usersService) Create(organizationID int, username, password string) (int, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	return service.CreateWithPasswordHash(organizationID, username, string(passwordHash))
+}
+
+func (service *usersService) CreateWithPasswordHash(organizationID int, username, passwordHash string) (int, error) {
+	if service.IsUsernameTaken(username) {
+		return 0, apperror.NewErrUserAlreadyExists()
+	}
+
+	publicID, err := uuid.NewV7()
+	if err != nil {
+		return 0, err
+	}
 
-	service.users = append(service.users, models.User{
-		ID:       lastID,
-		Username: username,
-		Password: password,
+	userID, err := service.users.Create(models.User{
+		PublicID:       publicID,
+		OrganizationID: organizationID,
+		Username:       username,
+		PasswordHash:   passwordHash,
+		Attributes:     map[string]any{},
+		Status:         models.UserActive,
+		CreatedAt:      time.Now(),
 	})
+	if errors.Is(err, repositories.ErrAlreadyExists) {
+		return 0, apperror.NewErrUserAlreadyExists()
+	} else if err != nil {
+		return 0, err
+	}
 
 	service.logger.Infof("[UsersService] New user created %s!", username)
 
-	return lastID, nil
+	return userID, nil
+}
+
+func (service *usersService) CheckPassword(user models.User, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func (service *usersService) GetByID(organizationID, id int) *models.User {
+	user := service.users.GetByID(id)
+	if !service.visible(organizationID, user, false) {
+		return nil
+	}
+
+	return user
 }
 
-func (service *usersService) GetByID(id int) *models.User {
-	for _, user := range service.users {
-		if user.ID == id {
-			return &user
+func (service *usersService) GetByPublicID(organizationID int, publicID string) *models.User {
+	user := service.users.GetByPublicID(publicID)
+	if !service.visible(organizationID, user, false) {
+		return nil
+	}
+
+	return user
+}
+
+func (service *usersService) GetByUsername(organizationID int, username string) *models.User {
+	return service.find(organizationID, username, false)
+}
+
+func (service *usersService) GetUsers(organizationID int) []models.User {
+	users := []models.User{}
+	for _, user := range service.users.GetAll() {
+		if service.visible(organizationID, &user, false) {
+			users = append(users, user)
 		}
 	}
 
-	return nil
+	return users
 }
 
-func (service *usersService) GetByUsername(username string) *models.User {
-	for _, user := range service.users {
-		if strings.EqualFold(user.Username, username) {
-			return &user
+// IsUsernameTaken also checks the deleted users, whose usernames stay
+// reserved until they are purged.
+func (service *usersService) IsUsernameTaken(username string) bool {
+	return service.users.GetByUsername(username) != nil
+}
+
+// HasUsers reports whether any user exists, counting the deleted ones.
+func (service *usersService) HasUsers() bool {
+	return len(service.users.GetAll()) > 0
+}
+
+func (service *usersService) IsEmailTaken(email string) bool {
+	return service.users.GetByEmail(email) != nil
+}
+
+func (service *usersService) QueryUsers(organizationID int, query UsersQuery) (*pagination.Page[models.User], error) {
+	users := []models.User{}
+	for _, user := range service.GetUsers(organizationID) {
+		if !strings.HasPrefix(strings.ToLower(user.Username), strings.ToLower(query.UsernamePrefix)) {
+			continue
+		}
+
+		if query.UserIDs != nil && !slices.Contains(query.UserIDs, user.ID) {
+			continue
 		}
+
+		users = append(users, user)
 	}
 
-	return nil
+	return pagination.Paginate(users, query.Query, usersSortFields, func(user models.User) string {
+		return user.PublicID
+	})
 }
 
-func (service *usersService) GetUsers() []models.User {
-	return service.users
+// UpdateUser keeps the user ID, so tokens, grants and memberships keep
+// working after a rename.
+func (service *usersService) UpdateUser(organizationID int, username string, update models.UserUpdate) (*models.User, error) {
+	user := service.find(organizationID, username, false)
+	if user == nil {
+		return nil, apperror.NewErrUserNotFound()
+	}
+
+	if update.Username != nil && !strings.EqualFold(*update.Username, user.Username) {
+		if service.IsUsernameTaken(*update.Username) {
+			return nil, apperror.NewErrUserAlreadyExists()
+		}
+	}
+
+	if update.Email != nil && *update.Email != "" && !strings.EqualFold(*update.Email, user.Email) {
+		if service.IsEmailTaken(*update.Email) {
+			return nil, apperror.NewErrEmailAlreadyExists()
+		}
+	}
+
+	if update.Username != nil {
+		user.Username = *update.Username
+	}
+
+	if update.Password != nil {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(*update.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+
+		user.PasswordHash = string(passwordHash)
+	}
+
+	if update.DisplayName != nil {
+		user.DisplayName = *update.DisplayName
+	}
+
+	if update.Email != nil {
+		user.Email = *update.Email
+	}
+
+	if update.Locale != nil {
+		user.Locale = *update.Locale
+	}
+
+	if update.Attributes != nil {
+		user.Attributes = maps.Clone(update.Attributes)
+	}
+
+	if err := service.update(user); err != nil {
+		return nil, err
+	}
+
+	service.logger.Infof("[UsersService] User %d updated!", user.ID)
+
+	return user, nil
 }
 
-func (service *usersService) DeleteUser(username string) error {
-	newUsers := []models.User{}
-	for _, user := range service.users {
-		if strings.EqualFold(user.Username, username) {
+// SuspendUser blocks the user until it is reactivated or, when until is not
+// nil, until that moment. Suspending a suspended user replaces the reason and
+// the end of the suspension.
+func (service *usersService) SuspendUser(organizationID int, username, reason string, until *time.Time) (*models.User, error) {
+	user := service.find(organizationID, username, false)
+	if user == nil {
+		return nil, apperror.NewErrUserNotFound()
+	}
+
+	user.Status = models.UserSuspended
+	user.SuspensionReason = reason
+	user.SuspendedUntil = until
+
+	if err := service.update(user); err != nil {
+		return nil, err
+	}
+
+	service.logger.Infof("[UsersService] User %d suspended!", user.ID)
+
+	return user, nil
+}
+
+func (service *usersService) ReactivateUser(organizationID int, username string) (*models.User, error) {
+	user := service.find(organizationID, username, false)
+	if user == nil {
+		return nil, apperror.NewErrUserNotFound()
+	}
+
+	reactivate(user)
+
+	if err := service.update(user); err != nil {
+		return nil, err
+	}
+
+	service.logger.Infof("[UsersService] User %d reactivated!", user.ID)
+
+	return user, nil
+}
+
+// RemoveAttribute removes the custom attribute called name from the users of
+// organizationID, deleted or not, once its definition is deleted.
+func (service *usersService) RemoveAttribute(organizationID int, name string) error {
+	for _, user := range service.users.GetAll() {
+		if _, ok := user.Attributes[name]; !ok || !inOrganization(organizationID, user.OrganizationID) {
 			continue
 		}
 
-		newUsers = append(newUsers, user)
+		delete(user.Attributes, name)
+
+		if err := service.update(&user); err != nil {
+			return err
+		}
 	}
 
-	if len(service.users) == len(newUsers) {
+	return nil
+}
+
+// DeleteUser only marks the user as deleted. It keeps its grants and
+// memberships, so RestoreUser gives them back until the user is purged.
+func (service *usersService) DeleteUser(organizationID int, username string) error {
+	user := service.find(organizationID, username, false)
+	if user == nil {
 		return apperror.NewErrUserNotFound()
 	}
 
-	service.users = newUsers
+	now := time.Now()
+	user.DeletedAt = &now
+
+	if err := service.update(user); err != nil {
+		return err
+	}
+
+	service.logger.Infof("[UsersService] User %d deleted!", user.ID)
 
 	return nil
 }
 
+func (service *usersService) RestoreUser(organizationID int, username string) (*models.User, error) {
+	user := service.find(organizationID, username, true)
+	if user == nil || time.Since(*user.DeletedAt) > service.options.RetentionPeriod {
+		return nil, apperror.NewErrUserNotFound()
+	}
+
+	user.DeletedAt = nil
+
+	if err := service.update(user); err != nil {
+		return nil, err
+	}
+
+	service.logger.Infof("[UsersService] User %d restored!", user.ID)
+
+	return user, nil
+}
+
+// PurgeDeletedUsers removes the users deleted longer than the retention
+// period ago and returns their IDs, so their grants can be removed too. Users
+// that cannot be removed are left for the next purge.
+func (service *usersService) PurgeDeletedUsers() []int {
+	purgedIDs := []int{}
+	for _, user := range service.users.GetAll() {
+		if user.DeletedAt == nil || time.Since(*user.DeletedAt) <= service.options.RetentionPeriod {
+			continue
+		}
+
+		if err := service.users.Delete(user.ID); err != nil {
+			service.logger.Infof("[UsersService] User %d not purged: %v", user.ID, err)
+			continue
+		}
+
+		purgedIDs = append(purgedIDs, user.ID)
+	}
+
+	if len(purgedIDs) > 0 {
+		service.logger.Infof("[UsersService] %d deleted users purged!", len(purgedIDs))
+	}
+
+	return purgedIDs
+}
+
+// ReactivateExpiredSuspensions stores the reactivation of the users whose
+// suspension expired, which lookups already report as active. Users that
+// cannot be updated are left for the next run.
+func (service *usersService) ReactivateExpiredSuspensions() {
+	reactivated := 0
+	for _, user := range service.users.GetAll() {
+		if !suspensionExpired(user) {
+			continue
+		}
+
+		reactivate(&user)
+		if err := service.users.Update(user); err != nil {
+			service.logger.Infof("[UsersService] User %d not reactivated: %v", user.ID, err)
+			continue
+		}
+
+		reactivated++
+	}
+
+	if reactivated > 0 {
+		service.logger.Infof("[UsersService] %d expired suspensions reactivated!", reactivated)
+	}
+}
+
 func NewUsersService(
 	logger logger.Logger,
+	options UsersOptions,
+
+	usersRepository repositories.UsersRepository,
 ) UsersService {
 	return &usersService{
 		BaseService: BaseService{
 			logger: logger,
 		},
-		users: []models.User{
-			{
-				ID:       1,
-				Username: "admin",
-				Password: "admin",
-			},
-			{
-				ID:       2,
-				Username: "dsolarte",
-				Password: "1234",
-			},
-		},
+
+		options: options,
+		users:   usersRepository,
 	}
 }
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/platform/logger"
+	"maps"
+	"strings"
+)
+
+type UserImportResult struct {
+	Error error
+
+	// TemporaryPassword is only set for invited users, once they are created.
+	TemporaryPassword string
+}
+
+// UsersImportService creates users with their grants in bulk and exports them
+// in the same format. organizationID is the scope of the importer: its users
+// can only be imported into organizations it can see.
+type UsersImportService interface {
+	Import(organizationID, importerID int, rows []models.UserImportRow, dryRun bool) ([]UserImportResult, bool)
+	Export(organizationID int, includePasswordHashes bool) []models.UserImportRow
+}
+
+type usersImportService struct {
+	BaseService
+
+	organizationsService  OrganizationsService
+	usersService          UsersService
+	permissionsService    PermissionsService
+	userAttributesService UserAttributesService
+}
+
+// rowOrganizationID resolves the organization of a row. Rows without one go
+// to the organization of the importer, or to the default one for platform
+// administrators.
+func (service *usersImportService) rowOrganizationID(organizationID int, row models.UserImportRow) (int, error) {
+	if row.OrganizationName == "" {
+		if organizationID == AllOrganizations {
+			return DefaultOrganizationID, nil
+		}
+
+		return organizationID, nil
+	}
+
+	organization := service.organizationsService.GetOrganizationByName(row.OrganizationName)
+	if organization == nil || !inOrganization(organizationID, organization.ID) {
+		return 0, apperror.NewErrOrganizationNotFound()
+	}
+
+	return organization.ID, nil
+}
+
+func (service *usersImportService) validateRow(organizationID, importerID int, row models.UserImportRow, usernames, emails map[string]bool) (int, error) {
+	rowOrganizationID, err := service.rowOrganizationID(organizationID, row)
+	if err != nil {
+		return 0, err
+	}
+
+	username := strings.ToLower(row.Username)
+	if usernames[username] || service.usersService.IsUsernameTaken(row.Username) {
+		return 0, apperror.NewErrUserAlreadyExists()
+	}
+
+	email := strings.ToLower(row.Email)
+	if email != "" && (emails[email] || service.usersService.IsEmailTaken(email)) {
+		return 0, apperror.NewErrEmailAlreadyExists()
+	}
+
+	for _, permissionName := range row.Permissions {
+		err := service.permissionsService.CanGrantPermission(rowOrganizationID, importerID, permissionName)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// The required attributes are checked even when the row has none.
+	if validationErrors := service.userAttributesService.Validate(rowOrganizationID, row.Attributes); len(validationErrors) > 0 {
+		return 0, apperror.NewErrValidation(validationErrors)
+	}
+
+	usernames[username] = true
+	if email != "" {
+		emails[email] = true
+	}
+
+	return rowOrganizationID, nil
+}
+
+func (service *usersImportService) importRow(organizationID int, row models.UserImportRow) UserImportResult {
+	result := UserImportResult{}
+
+	var userID int
+	var err error
+	if row.Invite {
+		result.TemporaryPassword, err = generatePassword()
+		if err != nil {
+			return UserImportResult{Error: err}
+		}
+
+		userID, err = service.usersService.Create(organizationID, row.Username, result.TemporaryPassword)
+	} else {
+		userID, err = service.usersService.CreateWithPasswordHash(organizationID, row.Username, row.PasswordHash)
+	}
+
+	if err != nil {
+		return UserImportResult{Error: err}
+	}
+
+	update := models.UserUpdate{}
+	if row.Email != "" {
+		update.Email = &row.Email
+	}
+
+	if row.DisplayName != "" {
+		update.DisplayName = &row.DisplayName
+	}
+
+	if row.Locale != "" {
+		update.Locale = &row.Locale
+	}
+
+	if len(row.Attributes) > 0 {
+		update.Attributes = row.Attributes
+	}
+
+	_, err = service.usersService.UpdateUser(organizationID, row.Username, update)
+	if err != nil {
+		return UserImportResult{Error: err}
+	}
+
+	for _, permissionName := range row.Permissions {
+		permission := service.permissionsService.GetPermissionByName(organizationID, permissionName)
+		if err := service.permissionsService.AssignPermissionToUser(userID, permission.ID); err != nil {
+			return UserImportResult{Error: err}
+		}
+	}
+
+	return result
+}
+
+// Import validates every row against the existing users and the previous
+// rows, and only creates the users when all of them are valid and dryRun is
+// false. Everything the creation checks is validated first, so it is only
+// reported as applied when every row was created. The returned results match
+// rows by index.
+func (service *usersImportService) Import(organizationID, importerID int, rows []models.UserImportRow, dryRun bool) ([]UserImportResult, bool) {
+	results := make([]UserImportResult, len(rows))
+	rowOrganizationIDs := make([]int, len(rows))
+	usernames := map[string]bool{}
+	emails := map[string]bool{}
+
+	valid := true
+	for i, row := range rows {
+		rowOrganizationIDs[i], results[i].Error = service.validateRow(organizationID, importerID, row, usernames, emails)
+		valid = valid && results[i].Error == nil
+	}
+
+	if !valid || dryRun {
+		return results, false
+	}
+
+	applied := true
+	for i, row := range rows {
+		results[i] = service.importRow(rowOrganizationIDs[i], row)
+		if results[i].Error != nil {
+			applied = false
+			service.logger.Infof("[UsersImportService] User %s not fully imported: %v", row.Username, results[i].Error)
+		}
+	}
+
+	service.logger.Infof("[UsersImportService] %d users imported!", len(rows))
+
+	return results, applied
+}
+
+// Export only includes the permissions granted directly to each user, since
+// groups are not part of the import. Without the password hashes the users
+// are exported as invites.
+func (service *usersImportService) Export(organizationID int, includePasswordHashes bool) []models.UserImportRow {
+	rows := []models.UserImportRow{}
+	for _, user := range service.usersService.GetUsers(organizationID) {
+		row := models.UserImportRow{
+			Username:    user.Username,
+			Invite:      !includePasswordHashes,
+			Email:       user.Email,
+			DisplayName: user.DisplayName,
+			Locale:      user.Locale,
+			Permissions: []string{},
+			Attributes:  maps.Clone(user.Attributes),
+		}
+
+		if includePasswordHashes {
+			row.PasswordHash = user.PasswordHash
+		}
+
+		if organization := service.organizationsService.GetOrganizationByID(user.OrganizationID); organization != nil {
+			row.OrganizationName = organization.Name
+		}
+
+		for _, userPermission := range service.permissionsService.GetPermissionsForUser(user.ID) {
+			permission := service.permissionsService.GetPermissionByID(AllOrganizations, userPermission.PermissionID)
+			if permission != nil {
+				row.Permissions = append(row.Permissions, permission.Name)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func NewUsersImportService(
+	logger logger.Logger,
+
+	organizationsService OrganizationsService,
+	usersService UsersService,
+	permissionsService PermissionsService,
+	userAttributesService UserAttributesService,
+) UsersImportService {
+	return &usersImportService{
+		BaseService: BaseService{
+			logger: logger,
+		},
+
+		organizationsService:  organizationsService,
+		usersService:          usersService,
+		permissionsService:    permissionsService,
+		userAttributesService: userAttributesService,
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"go-crud-gin/internal/apperror"
+	"go-crud-gin/internal/models"
+	"go-crud-gin/internal/repositories"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentSignUpsDoNotDuplicateUsernames(t *testing.T) {
+	services := newTestServices(t)
+
+	const usernames, attempts = 20, 10
+
+	var mutex sync.Mutex
+	created := map[string]int{}
+
+	calls := []func(){}
+	for i := 0; i < usernames; i++ {
+		for j := 0; j < attempts; j++ {
+			// The attempts change the case, since usernames are compared
+			// case-insensitively.
+			name := fmt.Sprintf("user%d", i)
+			username := name
+			if j%2 == 1 {
+				username = fmt.Sprintf("USER%d", i)
+			}
+
+			calls = append(calls, func() {
+				_, err := services.users.CreateWithPasswordHash(DefaultOrganizationID, username, "hash")
+				if err != nil {
+					if !hasErrorCode(err, apperror.ErrUserAlreadyExistsCode) {
+						t.Errorf("Create %s: %v", username, err)
+					}
+					return
+				}
+
+				mutex.Lock()
+				created[name]++
+				mutex.Unlock()
+			})
+		}
+	}
+
+	parallel(calls...)
+
+	for i := 0; i < usernames; i++ {
+		if got := created[fmt.Sprintf("user%d", i)]; got != 1 {
+			t.Errorf("user%d created %d times, want 1", i, got)
+		}
+	}
+
+	if got := len(services.users.GetUsers(DefaultOrganizationID)); got != usernames {
+		t.Errorf("got %d users, want %d", got, usernames)
+	}
+}
+
+func TestConcurrentDeletesRemoveEveryUser(t *testing.T) {
+	services := newTestServices(t)
+
+	const users = 50
+
+	calls := []func(){}
+	for i := 0; i < users; i++ {
+		username := fmt.Sprintf("user%d", i)
+		createTestUser(t, services, username)
+
+		for j := 0; j < 3; j++ {
+			calls = append(calls, func() {
+				err := services.users.DeleteUser(DefaultOrganizationID, username)
+				if err != nil && !hasErrorCode(err, apperror.ErrUserNotFoundCode) {
+					t.Errorf("DeleteUser %s: %v", username, err)
+				}
+			})
+		}
+
+		calls = append(calls, func() {
+			services.users.GetUsers(DefaultOrganizationID)
+			services.users.GetByUsername(DefaultOrganizationID, username)
+		})
+	}
+
+	parallel(calls...)
+
+	if got := services.users.GetUsers(DefaultOrganizationID); len(got) != 0 {
+		t.Errorf("got %d users left, want none", len(got))
+	}
+
+	for i := 0; i < users; i++ {
+		username := fmt.Sprintf("user%d", i)
+		if !services.users.IsUsernameTaken(username) {
+			t.Errorf("username %s was released by the delete", username)
+		}
+	}
+}
+
+func TestExpiredSuspensionsAreOnlyStoredByTheReactivation(t *testing.T) {
+	repository := repositories.NewMemoryStore().Users()
+	users := NewUsersService(testLogger{}, DefaultUsersOptions(), repository)
+
+	userID, err := users.CreateWithPasswordHash(DefaultOrganizationID, "suspended", "hash")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	until := time.Now().Add(time.Millisecond)
+	if _, err := users.SuspendUser(DefaultOrganizationID, "suspended", "spam", &until); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if user := users.GetByID(DefaultOrganizationID, userID); user.Status != models.UserActive {
+		t.Fatalf("GetByID reports the user as %s, want active", user.Status)
+	}
+
+	if user := repository.GetByID(userID); user.Status != models.UserSuspended {
+		t.Fatalf("a lookup stored the reactivation")
+	}
+
+	users.ReactivateExpiredSuspensions()
+
+	if user := repository.GetByID(userID); user.Status != models.UserActive || user.SuspendedUntil != nil {
+		t.Fatalf("ReactivateExpiredSuspensions left the user %s until %v", user.Status, user.SuspendedUntil)
+	}
+}